@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vimeo/go-taglog/taglog"
+)
+
+// ANSI escape sequences for colors
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorDim    = "\x1b[2m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorCyan   = "\x1b[36m"
+)
+
+// Writes records in a human readable form.
+type printer struct {
+	w          *bufio.Writer
+	color      bool
+	timeFormat string // layout for the time, empty to use the timestamp format of the input
+}
+
+func runCat(args []string) error {
+	fs := flag.NewFlagSet("cat", flag.ExitOnError)
+	var in paramFlags
+	in.register(fs, "", "input")
+	colorMode := fs.String("color", "auto", "colorize the output: auto, always, or never")
+	timeFormat := fs.String("time", "", "format of the time, e.g. \"TimestampFormatISO\" or a time.Format() layout (default the timestamp format of the input)")
+	strict := fs.Bool("strict", false, "stop at the first line which cannot be parsed")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: taglog cat [flags] [file ...]\n\nPrint logs in a colorized human readable form.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if err := in.check(fs); err != nil {
+		return err
+	}
+
+	p := &printer{
+		w:          bufio.NewWriter(os.Stdout),
+		timeFormat: taglog.ParseTimestampFormat(*timeFormat),
+	}
+	defer p.w.Flush()
+	switch *colorMode {
+	case "always":
+		p.color = true
+	case "never":
+		p.color = false
+	case "auto":
+		p.color = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	default:
+		return fmt.Errorf("Invalid color mode %q", *colorMode)
+	}
+
+	return eachInput(fs.Args(), func(name string, r io.Reader) error {
+		params, r, err := inputParams(fs, &in, r)
+		if err == taglog.ErrNoLogLines {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		parser := taglog.NewParser(params)
+		parser.SetStrict(*strict)
+		tsFormat := params.TimestampFormat
+		if tsFormat == "" {
+			tsFormat = taglog.GenTimestampFormat(params.TimestampFormatType, params.Flag)
+		}
+		for rec, err := range parser.Records(r) {
+			if err != nil {
+				return err
+			}
+			p.print(rec, tsFormat)
+		}
+		return nil
+	})
+}
+
+// Check whether a file is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Write a string with a color if colors are enabled.
+func (p *printer) colored(color string, s string) {
+	if p.color && color != "" {
+		p.w.WriteString(color)
+		p.w.WriteString(s)
+		p.w.WriteString(colorReset)
+		return
+	}
+	p.w.WriteString(s)
+}
+
+// Get the color for a level from its syslog severity.
+func levelColor(level string) string {
+	switch severity := taglog.DefaultLevelSet.Severity(level); {
+	case severity <= taglog.SeverityError:
+		return colorRed
+	case severity == taglog.SeverityWarning:
+		return colorYellow
+	case severity == taglog.SeverityNotice:
+		return colorBlue
+	case severity == taglog.SeverityInfo:
+		return colorGreen
+	}
+	return colorDim
+}
+
+// Write a record as the time, level, caller, message, and tags, followed by
+// the stack trace if it has one. tsFormat is the timestamp format of the
+// input.
+func (p *printer) print(rec taglog.Record, tsFormat string) {
+	tags := rec.Tags
+	sep := ""
+	field := func(color string, s string) {
+		p.w.WriteString(sep)
+		p.colored(color, s)
+		sep = " "
+	}
+
+	if !rec.Time.IsZero() {
+		layout := p.timeFormat
+		if layout == "" {
+			layout = tsFormat
+		}
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		field(colorDim, rec.Time.Format(layout))
+	} else if ts := tags.Get("timestamp"); ts != "" {
+		field(colorDim, ts)
+	}
+	tags.Del("timestamp")
+
+	// pad the level so the messages line up
+	if rec.Level != "" {
+		field(colorBold+levelColor(rec.Level), fmt.Sprintf("%-7s", rec.Level))
+	} else {
+		field("", "       ")
+	}
+	if caller := tags.Get("caller"); caller != "" {
+		field(colorDim, caller)
+	}
+	tags.Del("caller")
+
+	field("", rec.Message)
+
+	stack := tags.Get(taglog.StackTag)
+	tags.Del(taglog.StackTag)
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		color := colorCyan
+		if k == taglog.ErrorTag {
+			color = colorRed
+		}
+		p.w.WriteByte(' ')
+		p.colored(color, k+"=")
+		p.w.WriteString(strings.Join(tags.GetAll(k), ","))
+	}
+	p.w.WriteByte('\n')
+
+	if stack != "" {
+		for _, line := range strings.Split(stack, "\n") {
+			p.colored(colorDim, "    "+line)
+			p.w.WriteByte('\n')
+		}
+	}
+}
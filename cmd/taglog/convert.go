@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vimeo/go-taglog/taglog"
+)
+
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	var in, out paramFlags
+	in.register(fs, "", "input")
+	out.register(fs, "to-", "output")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: taglog convert [flags] [file ...]\n\n"+
+			"Convert logs to another format, timestamp format, or prefix. The output has\n"+
+			"the Params of the input, except those set with the -to- flags, and is written\n"+
+			"as a taglog Logger with those Params would have written it.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if err := in.check(fs); err != nil {
+		return err
+	}
+	if err := out.check(fs); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	return eachInput(fs.Args(), func(name string, r io.Reader) error {
+		params, r, err := inputParams(fs, &in, r)
+		if err == taglog.ErrNoLogLines {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		toParams := params
+		err = out.apply(fs, &toParams)
+		if err != nil {
+			return err
+		}
+		return taglog.Transcode(r, w, params, toParams)
+	})
+}
@@ -0,0 +1,187 @@
+// Command taglog reads logs written by the taglog package.
+//
+// Usage:
+//
+//	taglog cat [flags] [file ...]      print logs in a colorized human readable form
+//	taglog convert [flags] [file ...]  convert logs to another format or timestamp format
+//	taglog tags [flags] [file ...]     list the distinct tags in logs
+//
+// Files may be compressed with gzip. Standard input is read when no files are
+// given, or for "-". The Params of the input are detected from its first lines
+// unless they are set with flags.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vimeo/go-taglog/taglog"
+)
+
+const usage = `Usage: taglog <command> [flags] [file ...]
+
+Commands:
+  cat      print logs in a colorized human readable form
+  convert  convert logs to another format or timestamp format
+  tags     list the distinct tags in logs
+
+Run "taglog <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "cat":
+		err = runCat(os.Args[2:])
+	case "convert":
+		err = runConvert(os.Args[2:])
+	case "tags":
+		err = runTags(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "taglog: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taglog: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Flags which set Params. Flags which are not set keep the value of the
+// Params they are applied to.
+type paramFlags struct {
+	names     string // prepended to the flag names
+	format    string
+	logPrefix string
+	flags     string
+	timestamp string
+}
+
+// Register the flags with a FlagSet. desc describes the logs the Params are
+// for, e.g. "input".
+func (pf *paramFlags) register(fs *flag.FlagSet, names string, desc string) {
+	pf.names = names
+	fs.StringVar(&pf.format, names+"format", "", "format of the "+desc+": plain, json, or logfmt")
+	fs.StringVar(&pf.logPrefix, names+"prefix", "", "prefix of the "+desc+" lines")
+	fs.StringVar(&pf.flags, names+"flags", "", `flags of the `+desc+`, e.g. "LstdFlags|Lshortfile"`)
+	fs.StringVar(&pf.timestamp, names+"timestamp", "", `timestamp format of the `+desc+`, e.g. "TimestampFormatISO" or a time.Format() layout`)
+}
+
+// Override Params with the flags which are set.
+func (pf *paramFlags) apply(fs *flag.FlagSet, params *taglog.Params) error {
+	if isSet(fs, pf.names+"format") {
+		format := taglog.ParseFormat(pf.format)
+		if format < 0 {
+			return fmt.Errorf("Unknown format %q", pf.format)
+		}
+		params.Format = format
+	}
+	if isSet(fs, pf.names+"prefix") {
+		params.Prefix = pf.logPrefix
+	}
+	if isSet(fs, pf.names+"flags") {
+		params.Flag = taglog.ParseFlags(pf.flags)
+		params.TimestampFormat = ""
+	}
+	if isSet(fs, pf.names+"timestamp") {
+		params.TimestampFormat = taglog.ParseTimestampFormat(pf.timestamp)
+		switch params.TimestampFormat {
+		case taglog.TimestampFormatISO, taglog.TimestampFormatISOTime, taglog.TimestampFormatISODate:
+			params.TimestampFormatType = taglog.TimestampFormatTypeISO
+		case taglog.TimestampFormatStd, taglog.TimestampFormatStdTime, taglog.TimestampFormatStdDate:
+			params.TimestampFormatType = taglog.TimestampFormatTypeStd
+		default:
+			params.TimestampFormatType = taglog.TimestampFormatTypeUnknown
+		}
+	}
+	return nil
+}
+
+// Check the values of the flags before reading any input.
+func (pf *paramFlags) check(fs *flag.FlagSet) error {
+	var params taglog.Params
+	return pf.apply(fs, &params)
+}
+
+// Check whether a flag was set on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Get the Params of an input. Unless the format is set with a flag, the Params
+// are detected from the first lines of the input and the flags which are set
+// override them. The returned io.Reader must be read instead of r. An empty
+// input returns taglog.ErrNoLogLines.
+func inputParams(fs *flag.FlagSet, pf *paramFlags, r io.Reader) (taglog.Params, io.Reader, error) {
+	params := taglog.DefaultParams
+	if !isSet(fs, pf.names+"format") {
+		var err error
+		params, r, err = taglog.DetectParams(r, 0)
+		if err != nil {
+			return params, r, err
+		}
+	}
+	err := pf.apply(fs, &params)
+	return params, r, err
+}
+
+// Call fn for each input file, or for standard input if there are none. Files
+// compressed with gzip are decompressed.
+func eachInput(files []string, fn func(name string, r io.Reader) error) error {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		err := readInput(name, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readInput(name string, fn func(name string, r io.Reader) error) error {
+	var f io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		f = file
+	}
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	err := fn(name, r)
+	if err != nil && name != "-" {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return err
+}
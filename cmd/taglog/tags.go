@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/vimeo/go-taglog/taglog"
+)
+
+func runTags(args []string) error {
+	fs := flag.NewFlagSet("tags", flag.ExitOnError)
+	var in paramFlags
+	in.register(fs, "", "input")
+	keysOnly := fs.Bool("keys", false, "list only the keys")
+	strict := fs.Bool("strict", false, "stop at the first line which cannot be parsed")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: taglog tags [flags] [file ...]\n\n"+
+			"List the distinct tags in logs as key=value lines sorted by key, like\n"+
+			"Parser.Tags(). The timestamp, message, and caller are not tags.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if err := in.check(fs); err != nil {
+		return err
+	}
+
+	tags := make(taglog.Tags)
+	err := eachInput(fs.Args(), func(name string, r io.Reader) error {
+		params, r, err := inputParams(fs, &in, r)
+		if err == taglog.ErrNoLogLines {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		parser := taglog.NewParser(params)
+		parser.SetStrict(*strict)
+		err = parser.ParseInput(r)
+		if err != nil {
+			return err
+		}
+		tags.Import(parser.Tags())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	for _, k := range keys {
+		if *keysOnly {
+			fmt.Fprintln(w, k)
+			continue
+		}
+		for _, v := range tags.GetAll(k) {
+			fmt.Fprintf(w, "%s=%s\n", k, v)
+		}
+	}
+	return nil
+}
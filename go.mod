@@ -1 +1,3 @@
 module github.com/vimeo/go-taglog
+
+go 1.23
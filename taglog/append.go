@@ -0,0 +1,164 @@
+package taglog
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Append a tag value formatted as a string. See formatTagValue().
+func appendTagValue(b []byte, v interface{}) []byte {
+	switch vs := resolveTagValue(v).(type) {
+	case nil:
+		return b
+	case string:
+		return append(b, vs...)
+	case []string:
+		for i, s := range vs {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, s...)
+		}
+		return b
+	case int64:
+		return strconv.AppendInt(b, vs, 10)
+	case float64:
+		return appendFloat(b, vs)
+	case bool:
+		return strconv.AppendBool(b, vs)
+	case time.Time:
+		return vs.AppendFormat(b, time.RFC3339Nano)
+	default:
+		return append(b, formatTagValue(vs)...)
+	}
+}
+
+// Append a float formatted with formatFloat().
+func appendFloat(b []byte, f float64) []byte {
+	abs := math.Abs(f)
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.AppendFloat(b, f, 'e', -1, 64)
+	}
+	return strconv.AppendFloat(b, f, 'f', -1, 64)
+}
+
+// Append a tag value encoded as JSON, with the same result as encoding
+// jsonTagValue(v) with encoding/json.
+func appendJSONValue(b []byte, v interface{}) ([]byte, error) {
+	switch vs := resolveTagValue(v).(type) {
+	case nil:
+		return append(b, "null"...), nil
+	case string:
+		return appendJSONString(b, vs), nil
+	case []string:
+		if vs == nil {
+			return append(b, "null"...), nil
+		}
+		b = append(b, '[')
+		for i, s := range vs {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, s)
+		}
+		return append(b, ']'), nil
+	case int64:
+		return strconv.AppendInt(b, vs, 10), nil
+	case float64:
+		if math.IsNaN(vs) || math.IsInf(vs, 0) {
+			return appendJSONString(b, formatFloat(vs)), nil
+		}
+		return appendJSONFloat(b, vs), nil
+	case bool:
+		return strconv.AppendBool(b, vs), nil
+	case time.Duration:
+		return strconv.AppendInt(b, int64(vs), 10), nil
+	case time.Time:
+		if y := vs.Year(); y < 0 || y >= 10000 {
+			break
+		}
+		b = append(b, '"')
+		b = vs.AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"'), nil
+	}
+
+	jb, err := json.Marshal(jsonTagValue(v))
+	if err != nil {
+		return b, err
+	}
+	return append(b, jb...), nil
+}
+
+// Append a float the same way as encoding/json.
+func appendJSONFloat(b []byte, f float64) []byte {
+	abs := math.Abs(f)
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		b = strconv.AppendFloat(b, f, 'e', -1, 64)
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+		return b
+	}
+	return strconv.AppendFloat(b, f, 'f', -1, 64)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// Append a quoted JSON string, escaped the same way as encoding/json,
+// including HTML characters.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '\\', '"':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,216 @@
+package taglog
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// Policy used by asynchronous output when the queue is full.
+	AsyncBlock          = iota // wait for space in the queue
+	AsyncDropNewest            // discard the line being written
+	AsyncDropOldest            // discard the oldest queued line
+	AsyncDropBelowLevel        // discard the line being written if its level is below AsyncOptions.Level, otherwise wait
+)
+
+// Options for asynchronous output. See Logger.SetAsync.
+type AsyncOptions struct {
+	QueueSize int    // maximum number of queued lines, defaults to 1024
+	Policy    int    // policy when the queue is full
+	Level     string // minimum level which is never dropped with AsyncDropBelowLevel
+}
+
+type asyncLine struct {
+	out io.Writer
+	b   *[]byte // pooled buffer, returned to the pool once written or dropped
+}
+
+// A bounded queue of log lines drained by a background goroutine.
+type asyncWriter struct {
+	opts    AsyncOptions
+	queue   chan asyncLine
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	enqueued  uint64 // lines added to the queue
+	processed uint64 // lines removed from the queue, whether written or dropped
+	err       error  // first write error since the last Flush
+}
+
+func newAsyncWriter(opts AsyncOptions) *asyncWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	aw := new(asyncWriter)
+	aw.opts = opts
+	aw.queue = make(chan asyncLine, opts.QueueSize)
+	aw.done = make(chan struct{})
+	aw.cond = sync.NewCond(&aw.mu)
+	go aw.run()
+	return aw
+}
+
+func (aw *asyncWriter) run() {
+	defer close(aw.done)
+	for line := range aw.queue {
+		_, err := line.out.Write(*line.b)
+		putBuffer(line.b)
+		aw.mu.Lock()
+		if err != nil && aw.err == nil {
+			aw.err = err
+		}
+		aw.processed++
+		aw.cond.Broadcast()
+		aw.mu.Unlock()
+	}
+}
+
+// Queue a line, taking ownership of the buffer. below indicates that the level
+// of the line is below the level of AsyncDropBelowLevel.
+func (aw *asyncWriter) enqueue(out io.Writer, b *[]byte, below bool) {
+	line := asyncLine{out, b}
+
+	switch aw.opts.Policy {
+	case AsyncDropNewest:
+		select {
+		case aw.queue <- line:
+		default:
+			aw.dropped.Add(1)
+			putBuffer(b)
+			return
+		}
+	case AsyncDropOldest:
+		for sent := false; !sent; {
+			select {
+			case aw.queue <- line:
+				sent = true
+			default:
+				select {
+				case old := <-aw.queue:
+					aw.dropped.Add(1)
+					putBuffer(old.b)
+					aw.mu.Lock()
+					aw.processed++
+					aw.cond.Broadcast()
+					aw.mu.Unlock()
+				default:
+				}
+			}
+		}
+	case AsyncDropBelowLevel:
+		if below {
+			select {
+			case aw.queue <- line:
+			default:
+				aw.dropped.Add(1)
+				putBuffer(b)
+				return
+			}
+		} else {
+			aw.queue <- line
+		}
+	default:
+		aw.queue <- line
+	}
+
+	aw.mu.Lock()
+	aw.enqueued++
+	aw.mu.Unlock()
+}
+
+// Wait until all lines queued before the call have been written or dropped,
+// and return the first write error since the last flush.
+func (aw *asyncWriter) flush() error {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	target := aw.enqueued
+	for aw.processed < target {
+		aw.cond.Wait()
+	}
+	err := aw.err
+	aw.err = nil
+	return err
+}
+
+// Flush and stop the background goroutine. No lines may be queued afterwards.
+func (aw *asyncWriter) close() error {
+	err := aw.flush()
+	close(aw.queue)
+	<-aw.done
+	return err
+}
+
+// Enable asynchronous output. Lines are encoded by the calling goroutine and
+// written by a background goroutine, so a slow output does not stall callers
+// until the queue is full. What happens when it is full is controlled by the
+// policy. Calling SetAsync again replaces the queue after flushing it. Child
+// Loggers created with With() share the queue, but copies do not.
+func (this *Logger) SetAsync(opts AsyncOptions) {
+	aw := newAsyncWriter(opts)
+	this.mu.Lock()
+	old := this.async
+	this.async = aw
+	this.mu.Unlock()
+	if old != nil {
+		old.close()
+	}
+}
+
+// Wait until all queued lines have been written and return the first write
+// error since the last flush. It does nothing without asynchronous output.
+func (this *Logger) Flush() error {
+	this.mu.Lock()
+	aw := this.async
+	this.mu.Unlock()
+	if aw == nil {
+		return nil
+	}
+	return aw.flush()
+}
+
+// Flush the queue and stop asynchronous output. Subsequent lines are written
+// synchronously. The output Writer is not closed.
+func (this *Logger) Close() error {
+	this.mu.Lock()
+	aw := this.async
+	this.async = nil
+	this.mu.Unlock()
+	if aw == nil {
+		return nil
+	}
+	return aw.close()
+}
+
+// Get the number of lines dropped because the asynchronous queue was full.
+func (this *Logger) Dropped() uint64 {
+	this.mu.Lock()
+	aw := this.async
+	this.mu.Unlock()
+	if aw == nil {
+		return 0
+	}
+	return aw.dropped.Load()
+}
+
+// Enable asynchronous output for the Standard Logger.
+func SetAsync(opts AsyncOptions) {
+	std.SetAsync(opts)
+}
+
+// Wait until all lines queued by the Standard Logger have been written.
+func Flush() error {
+	return std.Flush()
+}
+
+// Flush the queue and stop asynchronous output for the Standard Logger.
+func Close() error {
+	return std.Close()
+}
+
+// Get the number of lines dropped by the Standard Logger.
+func Dropped() uint64 {
+	return std.Dropped()
+}
@@ -0,0 +1,181 @@
+package taglog
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// Buffers larger than this are not returned to the pool, so an occasional
+// huge line does not keep its memory alive.
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
+
+var entryPool = sync.Pool{
+	New: func() interface{} {
+		return new(Entry)
+	},
+}
+
+func putEntry(e *Entry) {
+	*e = Entry{}
+	entryPool.Put(e)
+}
+
+// An encoded tag, such as "[key=value]" in plain format or "key":value in JSON
+// format, stored in a shared buffer.
+type fragment struct {
+	key        string
+	start, end int
+}
+
+// A list of encoded tags which can be sorted in tag order.
+type fragments struct {
+	buf   []byte
+	list  []fragment
+	order tagOrder
+	text  bool       // sort by the encoded tags instead of tag order
+	stack stackTrace // written separately from the tags in plain format
+}
+
+var fragmentsPool = sync.Pool{
+	New: func() interface{} {
+		return new(fragments)
+	},
+}
+
+func getFragments() *fragments {
+	return fragmentsPool.Get().(*fragments)
+}
+
+func putFragments(f *fragments) {
+	if cap(f.buf) > maxPooledBuffer {
+		return
+	}
+	f.buf = f.buf[:0]
+	f.list = f.list[:0]
+	f.order = tagOrder{}
+	f.text = false
+	f.stack = ""
+	fragmentsPool.Put(f)
+}
+
+// Start a fragment for a key. It ends at the next call to end().
+func (f *fragments) begin(key string) {
+	f.list = append(f.list, fragment{key, len(f.buf), len(f.buf)})
+}
+
+func (f *fragments) end() {
+	f.list[len(f.list)-1].end = len(f.buf)
+}
+
+func (f *fragments) bytes(i int) []byte {
+	return f.buf[f.list[i].start:f.list[i].end]
+}
+
+func (f *fragments) Len() int {
+	return len(f.list)
+}
+
+func (f *fragments) Less(i, j int) bool {
+	if f.text {
+		return bytes.Compare(f.bytes(i), f.bytes(j)) < 0
+	}
+	return f.order.less(f.list[i].key, f.list[j].key)
+}
+
+// Check whether a tag encoded separately, such as the level, sorts before
+// fragment i.
+func (f *fragments) sortsBefore(key string, encoded []byte, i int) bool {
+	if f.text {
+		return bytes.Compare(encoded, f.bytes(i)) < 0
+	}
+	return f.order.less(key, f.list[i].key)
+}
+
+func (f *fragments) Swap(i, j int) {
+	f.list[i], f.list[j] = f.list[j], f.list[i]
+}
+
+// Encode tags as plain format fragments with nested keys joined with ".",
+// sorted in tag order if one was set and as text otherwise. With a tag order,
+// global tags keep the order of their values. A stack trace is kept
+// separately.
+func (f *fragments) addPlainTags(t Tags, o *tagOrder) {
+	for k, v := range flattenTags(t) {
+		if st, ok := v.(stackTrace); ok {
+			f.stack = st
+			continue
+		}
+		if k == "tags" {
+			switch vs := v.(type) {
+			case string:
+				f.addPlainGlobal(vs)
+				continue
+			case []string:
+				for _, v0 := range vs {
+					f.addPlainGlobal(v0)
+				}
+				continue
+			}
+		}
+		f.begin(k)
+		f.buf = appendPlainKey(f.buf, k)
+		f.buf = appendPlainValue(f.buf, v)
+		f.buf = append(f.buf, ']')
+		f.end()
+	}
+	f.order = *o
+	f.text = !o.set
+	sort.Stable(f)
+}
+
+func (f *fragments) addPlainGlobal(value string) {
+	f.begin("tags")
+	f.buf = append(f.buf, '[')
+	f.buf = appendPlainTag(f.buf, value)
+	f.buf = append(f.buf, ']')
+	f.end()
+}
+
+func appendPlainKey(b []byte, key string) []byte {
+	b = append(b, '[')
+	b = appendPlainTag(b, key)
+	return append(b, '=')
+}
+
+// Encode tags as JSON fields sorted in tag order.
+func (f *fragments) addJSONTags(t Tags, o *tagOrder) error {
+	for k, v := range t {
+		f.begin(k)
+		f.buf = appendJSONString(f.buf, k)
+		f.buf = append(f.buf, ':')
+		var err error
+		f.buf, err = appendJSONValue(f.buf, v)
+		if err != nil {
+			return err
+		}
+		f.end()
+	}
+	f.order = *o
+	sort.Sort(f)
+	return nil
+}
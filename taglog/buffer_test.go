@@ -0,0 +1,65 @@
+package taglog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// Create a Logger writing to io.Discard with a few tags, as a typical
+// request-scoped logger would have.
+func newBenchLogger(format int) *Logger {
+	logger := New(io.Discard, "app: ", LstdFlags)
+	logger.SetFormat(format)
+	logger.AddTag("service", "api")
+	logger.AddTag("region", "us-east-1")
+	logger.AddTag("", "global")
+	logger.SetTagValue("attempt", 3)
+	return logger
+}
+
+// Benchmark lines with only the cached tags of the Logger, and lines with
+// per-line tags which are encoded for each line.
+func benchmarkFormat(b *testing.B, format int) {
+	b.Run("static", func(b *testing.B) {
+		logger := newBenchLogger(format)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Lprint("INFO", "request handled")
+		}
+	})
+	b.Run("per-line", func(b *testing.B) {
+		logger := newBenchLogger(format)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Lprintw("INFO", "request handled", "status", 200, "path", "/v1/videos")
+		}
+	})
+}
+
+func BenchmarkPlain(b *testing.B) {
+	benchmarkFormat(b, FormatPlain)
+}
+
+func BenchmarkJSON(b *testing.B) {
+	benchmarkFormat(b, FormatJSON)
+}
+
+// Without a tag order, plain format tags are sorted by their encoded text,
+// including global tags and the level.
+func TestPlainDefaultOrder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "", 0)
+	logger.SetLevel(LevelDebug)
+	logger.AddTag("zeta", "1")
+	logger.AddTag("", "global", "alpha")
+	logger.AddTag("level2", "x")
+	logger.Lprint("INFO", "message")
+
+	want := "[alpha] [global] [level2=x] [level=INFO] [zeta=1] message\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package taglog
+
+// The tags of a Logger, which are the same for every line until the tags of
+// the Logger or its ancestors change, along with their encodings in the
+// built-in formats. Lines with per-line tags do not use the cache.
+type tagCache struct {
+	gen   uint64 // loggerCore.tagGen when the cache was built
+	tags  Tags
+	order tagOrder
+	plain *fragments // built on first use
+	json  *fragments // built on first use
+	err   error      // from encoding json
+}
+
+// Get the tags for a line without per-line tags. The result must not be
+// modified. The cache is nil if the tags contain lazily evaluated values. The
+// caller must hold the lock.
+func (this *Logger) staticTags() (Tags, *tagCache) {
+	if this.cache != nil && this.cache.gen == this.tagGen {
+		return this.cache.tags, this.cache
+	}
+
+	tags := this.lineTags(nil)
+	if hasMarshalers(tags) {
+		this.cache = nil
+		resolveTags(tags)
+		return tags, nil
+	}
+
+	this.cache = &tagCache{gen: this.tagGen, tags: tags, order: this.lineOrder()}
+	return tags, this.cache
+}
+
+// Note that the tags of this Logger have changed. The caller must hold the
+// lock.
+func (this *Logger) tagsChanged() {
+	this.tagGen++
+	this.keys = syncKeys(this.keys, this.tags)
+}
+
+func hasMarshalers(t Tags) bool {
+	for _, v := range t {
+		switch vs := v.(type) {
+		case Marshaler:
+			return true
+		case Tags:
+			if hasMarshalers(vs) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *tagCache) plainFragments() *fragments {
+	if c.plain == nil {
+		c.plain = new(fragments)
+		c.plain.addPlainTags(c.tags, &c.order)
+	}
+	return c.plain
+}
+
+func (c *tagCache) jsonFragments() (*fragments, error) {
+	if c.json == nil {
+		c.json = new(fragments)
+		c.err = c.json.addJSONTags(c.tags, &c.order)
+	}
+	return c.json, c.err
+}
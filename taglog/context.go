@@ -0,0 +1,216 @@
+package taglog
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+type contextKey struct{}
+
+// A function which extracts tags from a context, such as trace IDs or
+// deadlines. It may return nil if there is nothing to add.
+type ContextExtractor func(ctx context.Context) Tags
+
+// Create a new context carrying tags. Tags already carried by the parent
+// context are inherited using the same rules as Logger.With(). The tags are
+// copied.
+func NewContext(ctx context.Context, tags Tags) context.Context {
+	ctxTags := FromContext(ctx)
+	if ctxTags == nil {
+		ctxTags = make(Tags, len(tags))
+	}
+	newTags := tags.Copy()
+	if _, found := newTags[""]; found {
+		newTags.Add("tags", newTags.GetAll("")...)
+		newTags.Del("")
+	}
+	layerTags(ctxTags, newTags)
+	return context.WithValue(ctx, contextKey{}, ctxTags)
+}
+
+// Create a new context carrying one or more values for a key. See NewContext().
+func ContextWith(ctx context.Context, key string, value ...string) context.Context {
+	tags := make(Tags)
+	tags.Add(key, value...)
+	return NewContext(ctx, tags)
+}
+
+// Get a copy of the tags carried by a context. If the context does not carry
+// any tags, nil is returned.
+func FromContext(ctx context.Context) Tags {
+	if ctx == nil {
+		return nil
+	}
+	tags, ok := ctx.Value(contextKey{}).(Tags)
+	if !ok {
+		return nil
+	}
+	return tags.Copy()
+}
+
+// Add a function which extracts additional tags from the context passed to the
+// *Ctx methods. Extractors are called in the order they were added, and their
+// tags are layered on top of the tags carried by the context.
+func (this *Logger) AddContextExtractor(fn ContextExtractor) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.extractors = append(this.extractors[:len(this.extractors):len(this.extractors)], fn)
+}
+
+// Collect the tags for a context and layer the per-line tags on top of them.
+func (this *Logger) contextTags(ctx context.Context, extra Tags) Tags {
+	if ctx == nil {
+		return extra
+	}
+
+	this.mu.Lock()
+	extractors := this.extractors
+	this.mu.Unlock()
+
+	tags := FromContext(ctx)
+	for _, fn := range extractors {
+		if fnTags := fn(ctx); len(fnTags) > 0 {
+			if tags == nil {
+				tags = make(Tags)
+			}
+			layerTags(tags, fnTags)
+		}
+	}
+	if tags == nil {
+		return extra
+	}
+	layerTags(tags, extra)
+	return tags
+}
+
+// Write a log line with the tags from a context and additional per-line tags.
+// calldepth is relative to the caller of outputCtx.
+func (this *Logger) outputCtx(calldepth int, ctx context.Context, level string, s string, extra Tags) error {
+	now := time.Now()
+
+	// walk the stack without holding the lock since it can be expensive
+	this.mu.Lock()
+	wantCaller := this.params.Flag&(Lshortfile|Llongfile) != 0 || this.handler != nil
+	skip := this.callerSkip
+	this.mu.Unlock()
+
+	var pc uintptr
+	if wantCaller {
+		var pcs [1]uintptr
+		if runtime.Callers(calldepth+skip+1, pcs[:]) > 0 {
+			pc = pcs[0]
+		}
+	}
+
+	return this.write(ctx, now, pc, level, s, this.contextTags(ctx, extra))
+}
+
+// Same as OutputDepth, but adds the tags from a context and per-line tags to
+// the line. Either may be nil.
+func (this *Logger) OutputDepthCtx(calldepth int, ctx context.Context, s string, tags Tags) error {
+	return this.outputCtx(calldepth+1, ctx, this.standardLevel, s, tags)
+}
+
+// Same as LoutputDepth, but adds the tags from a context and per-line tags to
+// the line. Either may be nil.
+func (this *Logger) LoutputDepthCtx(calldepth int, ctx context.Context, level string, s string, tags Tags) error {
+	return this.outputCtx(calldepth+1, ctx, level, s, tags)
+}
+
+// Same as Printf, but adds the tags from a context to the line.
+func (this *Logger) PrintfCtx(ctx context.Context, format string, v ...interface{}) {
+	if !this.Enabled(this.standardLevel) {
+		return
+	}
+	this.outputCtx(2, ctx, this.standardLevel, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Same as Print, but adds the tags from a context to the line.
+func (this *Logger) PrintCtx(ctx context.Context, v ...interface{}) {
+	if !this.Enabled(this.standardLevel) {
+		return
+	}
+	this.outputCtx(2, ctx, this.standardLevel, fmt.Sprint(v...), argTags(v))
+}
+
+// Same as Lprintf, but adds the tags from a context to the line.
+func (this *Logger) LprintfCtx(ctx context.Context, level string, format string, v ...interface{}) {
+	if !this.Enabled(level) {
+		return
+	}
+	this.outputCtx(2, ctx, level, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Same as Lprint, but adds the tags from a context to the line.
+func (this *Logger) LprintCtx(ctx context.Context, level string, v ...interface{}) {
+	if !this.Enabled(level) {
+		return
+	}
+	this.outputCtx(2, ctx, level, fmt.Sprint(v...), argTags(v))
+}
+
+// Same as Lprintw, but adds the tags from a context to the line. Per-line tags
+// take precedence over the tags from the context.
+func (this *Logger) LprintwCtx(ctx context.Context, level string, msg string, kv ...interface{}) {
+	if !this.Enabled(level) {
+		return
+	}
+	this.outputCtx(2, ctx, level, msg, kvTags(kv))
+}
+
+// Add a context extractor to the Standard Logger.
+func AddContextExtractor(fn ContextExtractor) {
+	std.AddContextExtractor(fn)
+}
+
+// See Logger.OutputDepthCtx
+func OutputDepthCtx(calldepth int, ctx context.Context, s string, tags Tags) error {
+	return std.outputCtx(calldepth+1, ctx, std.standardLevel, s, tags)
+}
+
+// See Logger.LoutputDepthCtx
+func LoutputDepthCtx(calldepth int, ctx context.Context, level string, s string, tags Tags) error {
+	return std.outputCtx(calldepth+1, ctx, level, s, tags)
+}
+
+// See Logger.PrintfCtx
+func PrintfCtx(ctx context.Context, format string, v ...interface{}) {
+	if !std.Enabled(std.standardLevel) {
+		return
+	}
+	std.outputCtx(2, ctx, std.standardLevel, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// See Logger.PrintCtx
+func PrintCtx(ctx context.Context, v ...interface{}) {
+	if !std.Enabled(std.standardLevel) {
+		return
+	}
+	std.outputCtx(2, ctx, std.standardLevel, fmt.Sprint(v...), argTags(v))
+}
+
+// See Logger.LprintfCtx
+func LprintfCtx(ctx context.Context, level string, format string, v ...interface{}) {
+	if !std.Enabled(level) {
+		return
+	}
+	std.outputCtx(2, ctx, level, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// See Logger.LprintCtx
+func LprintCtx(ctx context.Context, level string, v ...interface{}) {
+	if !std.Enabled(level) {
+		return
+	}
+	std.outputCtx(2, ctx, level, fmt.Sprint(v...), argTags(v))
+}
+
+// See Logger.LprintwCtx
+func LprintwCtx(ctx context.Context, level string, msg string, kv ...interface{}) {
+	if !std.Enabled(level) {
+		return
+	}
+	std.outputCtx(2, ctx, level, msg, kvTags(kv))
+}
@@ -0,0 +1,293 @@
+package taglog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Returned by DetectParams() when the input has no log lines.
+var ErrNoLogLines = fmt.Errorf("No log lines")
+
+// The number of lines sampled by DetectParams() when n is not positive.
+const defaultDetectLines = 100
+
+// The maximum length of a prefix found by DetectParams() in plain format.
+const maxDetectPrefix = 64
+
+// Flags for the timestamp formats which can be detected, most specific first.
+var detectTimestampFlags = []int{
+	Ldate | Ltime | Lmicroseconds,
+	Ldate | Ltime | Lmilliseconds,
+	Ldate | Ltime,
+	Ldate,
+	Ltime | Lmicroseconds,
+	Ltime | Lmilliseconds,
+	Ltime,
+}
+
+// Detect the Params of a log by sampling its first n lines, or 100 lines if n
+// is not positive. The format (plain, JSON, or logfmt), the prefix, the
+// timestamp format, and the Llongfile and Lshortfile flags are detected. The
+// timestamp format is one generated by GenTimestampFormat() from a timestamp
+// format type and flags. The returned io.Reader reads the whole input,
+// including the sampled lines, so it can be passed to a Parser created with the
+// Params.
+func DetectParams(input io.Reader, n int) (Params, io.Reader, error) {
+	if n <= 0 {
+		n = defaultDetectLines
+	}
+
+	br := bufio.NewReader(input)
+	sample := new(bytes.Buffer)
+	var lines []string
+	for len(lines) < n {
+		s, err := br.ReadString('\n')
+		sample.WriteString(s)
+		s = strings.TrimRight(s, "\r\n")
+		if s != "" && !strings.HasPrefix(s, "\t") {
+			lines = append(lines, s)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Params{}, nil, err
+		}
+	}
+	replay := io.MultiReader(sample, br)
+
+	if len(lines) == 0 {
+		return Params{}, replay, ErrNoLogLines
+	}
+	if params, ok := detectStructured(lines, FormatJSON, ""); ok {
+		return params, replay, nil
+	}
+	if params, ok := detectStructured(lines, FormatLogfmt, detectLogfmtPrefix(lines[0])); ok {
+		return params, replay, nil
+	}
+	return detectPlain(lines), replay, nil
+}
+
+// Detect the Params of lines in JSON or logfmt format from the "timestamp" and
+// "caller" tags. false is returned if fewer than half of the lines can be
+// decoded in the format.
+func detectStructured(lines []string, format int, prefix string) (Params, bool) {
+	params := DefaultParams
+	params.Format = format
+	params.Prefix = prefix
+	params.Flag = 0
+
+	dec := FormatDecoder(format)
+	var timestamps, callers []string
+	decoded := 0
+	for _, line := range lines {
+		tags, err := dec.Decode(line, &params)
+		if err != nil {
+			continue
+		}
+		decoded++
+		if ts := tags.Get("timestamp"); ts != "" {
+			timestamps = append(timestamps, ts)
+		}
+		if caller := tags.Get("caller"); caller != "" {
+			callers = append(callers, caller)
+		}
+	}
+	if decoded*2 < len(lines) {
+		return Params{}, false
+	}
+
+	params.TimestampFormatType, params.Flag = detectTimestamp(timestamps)
+	if len(callers) == decoded {
+		params.Flag |= detectCaller(callers)
+	}
+	return params, true
+}
+
+// Find the timestamp format type and flags which format all of the timestamps
+// exactly. DefaultParams are returned if there is none.
+func detectTimestamp(timestamps []string) (int, int) {
+	if len(timestamps) == 0 {
+		return DefaultParams.TimestampFormatType, 0
+	}
+	for _, typ := range []int{TimestampFormatTypeISO, TimestampFormatTypeStd} {
+	flags:
+		for _, flag := range detectTimestampFlags {
+			layout := GenTimestampFormat(typ, flag)
+			for _, ts := range timestamps {
+				if !isTimestamp(ts, layout) {
+					continue flags
+				}
+			}
+			return typ, flag
+		}
+	}
+	return DefaultParams.TimestampFormatType, 0
+}
+
+// Check whether a string is a timestamp written with a layout. time.Parse()
+// accepts fractional seconds which are not in the layout, so the timestamp
+// must also be formatted back unchanged.
+func isTimestamp(s string, layout string) bool {
+	ts, err := time.Parse(layout, s)
+	return err == nil && ts.Format(layout) == s
+}
+
+// Get the Llongfile or Lshortfile flag for callers formatted as "file:line", or
+// 0 if any of them is not a caller.
+func detectCaller(callers []string) int {
+	flag := Lshortfile
+	for _, caller := range callers {
+		colon := strings.LastIndexByte(caller, ':')
+		if colon <= 0 || colon == len(caller)-1 || strings.ContainsAny(caller, " [") {
+			return 0
+		}
+		if strings.Trim(caller[colon+1:], "0123456789") != "" {
+			return 0
+		}
+		if strings.Contains(caller[:colon], "/") {
+			flag = Llongfile
+		}
+	}
+	return flag
+}
+
+// Get the text before the first key written by the Logger in a logfmt line.
+func detectLogfmtPrefix(line string) string {
+	end := -1
+	for _, key := range []string{"time=", "level=", "caller=", "msg="} {
+		for i := 0; i < len(line); {
+			j := strings.Index(line[i:], key)
+			if j < 0 {
+				break
+			}
+			j += i
+			if j == 0 || line[j-1] == ' ' {
+				if end < 0 || j < end {
+					end = j
+				}
+				break
+			}
+			i = j + 1
+		}
+	}
+	if end < 0 {
+		return ""
+	}
+	return line[:end]
+}
+
+// Detect the Params of lines in plain format. Each timestamp format is
+// searched for in the lines, and the text before it is the prefix. The Params
+// which decode the most lines are returned, preferring the more specific
+// timestamp formats.
+func detectPlain(lines []string) Params {
+	best := DefaultParams
+	best.Flag = 0
+	bestCount := 0
+	for _, typ := range []int{TimestampFormatTypeISO, TimestampFormatTypeStd} {
+		for _, flag := range detectTimestampFlags {
+			layout := GenTimestampFormat(typ, flag)
+			prefix, found := "", false
+			for _, line := range lines {
+				if prefix, found = findTimestamp(line, layout); found {
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+
+			params := DefaultParams
+			params.TimestampFormatType = typ
+			params.Flag = flag
+			params.Prefix = prefix
+			if count := detectPlainCaller(lines, &params); count > bestCount {
+				best = params
+				bestCount = count
+			}
+		}
+	}
+
+	if bestCount == 0 {
+		best.Prefix = detectPlainPrefix(lines)
+		detectPlainCaller(lines, &best)
+	}
+	return best
+}
+
+// Get the prefix of lines in plain format without a timestamp: the text which
+// all of the lines start with, up to the first tag or the last space. Text
+// which looks like a tag with a key is not a prefix.
+func detectPlainPrefix(lines []string) string {
+	if len(lines) < 2 {
+		return ""
+	}
+	prefix := lines[0]
+	for _, line := range lines[1:] {
+		n := 0
+		for n < len(prefix) && n < len(line) && prefix[n] == line[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+
+	if i := strings.Index(prefix, " ["); i >= 0 {
+		prefix = prefix[:i+1]
+	} else {
+		prefix = prefix[:strings.LastIndexByte(prefix, ' ')+1]
+	}
+	if strings.Contains(prefix, "=") {
+		return ""
+	}
+	return prefix
+}
+
+// Add the caller flag to plain format Params if the lines have callers, and
+// get the number of lines which can be decoded.
+func detectPlainCaller(lines []string, params *Params) int {
+	var callers []string
+	count := 0
+	for _, line := range lines {
+		tags, _, err := decodePlain(line, params)
+		if err != nil {
+			continue
+		}
+		count++
+		msg := tags.Get("msg")
+		end := strings.Index(msg, ": ")
+		if end < 0 && strings.HasSuffix(msg, ":") {
+			end = len(msg) - 1
+		}
+		if end >= 0 {
+			callers = append(callers, msg[:end])
+		}
+	}
+	if count == 0 || len(callers) < count {
+		return count
+	}
+	params.Flag |= detectCaller(callers)
+	return count
+}
+
+// Find a timestamp with a layout in a line, and get the text before it.
+func findTimestamp(line string, layout string) (string, bool) {
+	tokens := len(strings.Split(layout, " "))
+	for i := 0; i < len(line) && i <= maxDetectPrefix; i++ {
+		if line[i] < '0' || line[i] > '9' {
+			continue
+		}
+		fields := strings.SplitN(line[i:], " ", tokens+1)
+		if len(fields) < tokens {
+			break
+		}
+		if isTimestamp(strings.Join(fields[:tokens], " "), layout) {
+			return line[:i], true
+		}
+	}
+	return "", false
+}
@@ -11,6 +11,8 @@
        - Advanced extra features
            - Add tags to the log format to add context to log messages and allow for easier machine processing
            - Output log lines in JSON format
+           - Output log lines in logfmt format (FormatLogfmt)
+           - Send log lines to syslog in RFC 5424 or RFC 3164 format (DialSyslog)
            - Provides a pre-defined timestamp format that is compatible with elasticsearch (TimestampFormatISO)
 
    Drop-in Replacement
@@ -25,7 +27,9 @@
 
    Conflicts
 
-       - The flags Llongfile and Lshortfile are defined for compatibility, but they do not have any effect
+       - With Llongfile or Lshortfile, the caller is printed after the timestamp in plain format and
+         exported in the "caller" field in JSON format (see SetCallerTag())
+           - Wrappers can use OutputDepth(), LoutputDepth(), or SetCallerSkip() to skip their own stack frames
        - The flags Ldate, Ltime, and Lmicroseconds only apply when not using a custom timestamp format
            - If SetTimestampFormat() is called with an undefined value, the flags are subsequently ignored
            - If SetTimestampFormatType() is called, the timestamp format is reset and the flags will be used
@@ -36,6 +40,35 @@
    You can add, delete, get, set, push, and pop tags as key/value strings. A single
    key can have multiple values.
 
+   SetTagValue() sets a typed value for a key: int64, float64, bool, time.Time,
+   time.Duration, nested maps, or a Marshaler. Typed values are written natively
+   in JSON format (durations as integer nanoseconds), and formatted as strings in
+   plain and logfmt formats, where nested maps are flattened into keys joined by
+   ".". ExportTags() and GetTag() return typed values formatted as strings.
+   A func() string value is only called for lines which are written, so
+   expensive tag values cost nothing when the level is filtered out.
+
+   Child loggers created with With() or WithTags() share the output, parameters,
+   and level of their parent and inherit its tags, but have their own tags which
+   do not affect the parent. This allows concurrent request handlers to add
+   context without racing on a shared Logger. Tags for a single line can be
+   passed as alternating keys and values to Printw() and Lprintw().
+
+   Request-scoped tags can be carried by a context.Context with NewContext() and
+   retrieved with FromContext(). The *Ctx methods, such as LprintfCtx(), add the
+   tags from the context to the line without modifying the tags of the Logger.
+   AddContextExtractor() registers functions which derive additional tags from a
+   context, such as trace IDs.
+
+   By default, tags are written sorted by key, and in plain format they are
+   sorted by their encoded text so global tags are mixed in with the others.
+   SetTagOrder() sorts them by key in every format, or writes them in the
+   order they were added to the Logger and its ancestors with TagOrderInsertion,
+   and can list keys to write first, such as SetTagOrder(TagOrderInsertion,
+   "level", "request_id"). Per-line tags and tags from a context follow in
+   alphabetical order. The order applies to the plain, JSON, logfmt, and syslog
+   formats, and the Parser keeps the order of the input when converting to JSON.
+
    Special-case tags:
 
        - In JSON format, the "timestamp" and "msg" tags are overwritten when logging a line
@@ -44,6 +77,154 @@
            - In plain format, global tags have the key omitted and are printed separately
            - In JSON format, global tags are exported in the "tags" field
            - Using GetTag() with either "" or "tags" will access global tags
+       - In logfmt format, the timestamp is written as "time", followed by the level, caller, and "msg"
+           - Tags with multiple values are written as a repeated key, one key=value pair per value
+           - Values are quoted with Go string quoting when they contain spaces, "=", quotes, or control characters
+       - In plain format, tags come before the message and are escaped with backslashes so lines can be parsed back unchanged
+           - "\", "[", "]", "=", and "," in tag keys and values are escaped, e.g. [path=a\=b]
+           - Newlines and carriage returns are written as \n and \r, and a message starting with "[" is written as \[
+           - A backslash is only escaped when it would otherwise start an escape sequence, so most text is unchanged
+
+   Levels
+
+   Lines are written at a level with Lprintf() and friends, or with the
+   level-specific methods Debugf(), Infof(), Noticef(), Warningf(), Errorf(),
+   Criticalf(), Alertf(), and Emergencyf(), and their non-f variants. These are
+   generated from DefaultLevelSet and avoid typos in level names. They are part of
+   the Leveled interface, which is implemented by both Logger and MultiLogger.
+   The level is checked before the message is formatted, and Enabled() reports
+   whether lines at a level would be written.
+
+   Errors
+
+   LogError() writes an error in dedicated tags: "error" with its message,
+   "error_type" with its Go type, and "error_chain" with the type and message of
+   each error it wraps, found with errors.Unwrap() or errors.Join(). These are
+   typed fields in JSON format. SetStackLevel() captures the stack of the caller
+   for errors at or above a level in the "stack" tag, which is written in plain
+   format as a block of tab-indented lines after the line. The Parser reads the
+   block back into the "stack" tag, and ParseLine() does not keep these tags.
+
+   TagError() attaches tags to an error where it occurs, such as a job ID, and
+   the error can be wrapped further with fmt.Errorf("%w"). When an error is
+   logged, as an argument of a print method, a value for Printw(), or with
+   LogError(), the tags attached anywhere in its chain are added to the line.
+   Tags from outer errors replace tags from the errors they wrap, and per-line
+   keys and values replace both. ErrorTags() collects the tags of an error.
+
+   MultiLogger
+
+   A MultiLogger writes each line to several loggers and applies setters to all of
+   them. Logger and MultiLogger both implement Interface, which covers tags,
+   parameters, and output, so functions can accept either. A MultiLogger can
+   contain Loggers, other MultiLoggers, or any other implementation of Interface.
+
+   Loggers added with Add() have a name and a Route, which selects the lines they
+   receive by minimum level and by tag predicates. Child() returns a named logger,
+   so setters can target it alone, e.g. Child("stderr").SetFormat(FormatPlain).
+
+   SetFanOut() controls how lines are written to the loggers. With Parallel they
+   are written concurrently, and a logger which does not finish within its timeout
+   is reported without delaying the others. Errors from all loggers are combined
+   with errors.Join as ChildErrors which identify the logger. A logger which fails
+   repeatedly is disabled for a cooldown period and reported to OnDisable.
+
+   Asynchronous Output
+
+   By default, lines are written to the output Writer by the goroutine which logs
+   them. SetAsync() enables a bounded queue drained by a background goroutine, so
+   a slow output does not stall the program. AsyncOptions selects what happens
+   when the queue is full: block (AsyncBlock), drop the new line
+   (AsyncDropNewest), drop the oldest queued line (AsyncDropOldest), or drop new
+   lines below a level (AsyncDropBelowLevel). Dropped() counts dropped lines.
+   Flush() waits for queued lines to be written, and Close() flushes and returns
+   to synchronous output. The Fatal functions flush before exiting.
+
+   Rotating Files
+
+   OpenRotatingFile() opens a file which can be used as the output Writer and
+   rotates it when it reaches a maximum size or age, keeping a number of backups
+   which are optionally compressed with gzip in the background. Reopen() and
+   ReopenOnSignal() reopen the file after it has been moved by an external tool.
+
+   Syslog
+
+   DialSyslog() connects to a syslog server over a unix socket, UDP, or TCP, and
+   reconnects when a write fails. It is used as the output Writer with
+   FormatSyslog5424 or FormatSyslog3164. The priority of each message is
+   calculated from the syslog severity of its level, which LevelSet.Severity()
+   derives from the level name unless it is set with SetSeverity(). The prefix is
+   used as the app-name, and in RFC 5424 format tags are written as structured
+   data. NewSyslogEncoder() creates encoders with other facilities and hostnames.
+
+   Reading Logs
+
+   A Parser reads logs written in a format with the same Params. Records()
+   iterates over the lines of an io.Reader as Records with the time, level,
+   message, remaining tags, raw input, and line number of each line:
+
+       for rec, err := range taglog.NewParser(params).Records(input) {
+           ...
+       }
+
+   Next() reads one Record at a time from the input set with SetInput(). Lines
+   which cannot be decoded, such as the rest of a message containing newlines,
+   are added to the message of the previous Record, and a stack trace written
+   after a line in plain format is read into its "stack" tag. ToJSON() converts
+   logs to JSON format the same way. The level is read from the "level" tag, or
+   the tag set with SetLevelTag() for logs written with another level tag.
+
+   Lines which cannot be parsed produce a ParseError with the line number, the
+   byte offset in the line, and the component which failed, such as "prefix",
+   "timestamp", or "msg". By default the Parser is lenient: ParseInput() skips
+   these lines and Next() adds them to the previous message as above, and the
+   first 100 errors are collected for Errors() while ErrorCount() counts them all.
+   SetStrict(true) makes ParseInput(), Next(), and ToJSON() return the first
+   ParseError instead. Plain, JSON, and logfmt input are handled the same way.
+
+   Transcode() converts a log written with one set of Params to another, such as
+   plain format to JSON or a different timestamp format or prefix, and
+   JSONToPlain() converts JSON format to plain. Lines are encoded by the same code
+   as a Logger, so the output is the same as a Logger with the new Params would
+   have written, including the level and global tags.
+
+   DetectParams() samples the first lines of a log and returns Params for
+   NewParser() when they are not known. It detects the format (plain, JSON, or
+   logfmt), the prefix, the timestamp format among those generated by
+   GenTimestampFormat(), and the Llongfile and Lshortfile flags. It also returns
+   an io.Reader which reads the whole log, including the sampled lines:
+
+       params, input, err := taglog.DetectParams(input, 100)
+       ...
+       err = taglog.NewParser(params).ParseInput(input)
+
+   The taglog command in cmd/taglog reads logs with a Parser. "taglog cat" prints
+   them in a colorized human readable form, "taglog convert" converts them with
+   Transcode(), e.g. with -to-format json or -to-timestamp TimestampFormatISO, and
+   "taglog tags" lists the distinct tags. It reads files, files compressed with
+   gzip, or standard input, and detects the Params of the input unless they are
+   set with the -format, -prefix, -flags, and -timestamp flags.
+
+   Custom Formats
+
+   Log lines are encoded by an Encoder selected by the log format. Custom formats
+   can be added with RegisterFormat(), which returns a format for use with
+   SetFormat() and NewParser(), and makes the name available to ParseFormat(). A
+   Decoder can be registered along with the Encoder so the Parser can read the
+   format.
+
+   The built-in formats encode lines into pooled buffers, and the tags of a Logger
+   are encoded once and reused until they change, so a line with no per-line tags
+   allocates little beyond its timestamp. An Encoder must not modify the Entry or
+   retain it after returning.
+
+   log/slog
+
+   NewSlogHandler() creates a slog.Handler which writes through a Logger, so code
+   using log/slog produces taglog lines. slog levels are mapped onto the LevelSet
+   of the Logger with SlogLevelName(), and attributes become tags, with groups
+   flattened into keys joined by "." (e.g. "http.status"). NewSlogLogger()
+   does the reverse and creates a Logger which forwards lines to a slog.Handler.
 
    Defaults
 
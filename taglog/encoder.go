@@ -0,0 +1,391 @@
+package taglog
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A single log line passed to an Encoder.
+type Entry struct {
+	Time      time.Time // time of the line, zero if unknown
+	Timestamp string    // Time formatted with the timestamp format, empty if omitted
+	Level     string    // upper case level, empty if the line has no level
+	LevelTag  string    // tag used for the level, empty if the level is not written
+	Severity  int       // syslog severity of the level, see LevelSet.Severity
+	Message   string
+	Caller    string // formatted according to Llongfile and Lshortfile, empty if omitted
+	CallerTag string // tag used for the caller in formats without a dedicated position
+	Tags      Tags   // tags for the line, excluding the level and caller
+	Params    Params
+
+	order tagOrder  // see SetTagOrder
+	cache *tagCache // set when Tags are the cached tags of the Logger
+}
+
+// Get the tags for the line, including the level and caller tags. The result
+// is a new Tags map.
+func (e *Entry) AllTags() Tags {
+	tags := make(Tags, len(e.Tags)+2)
+	for k, v := range e.Tags {
+		tags[k] = v
+	}
+	if e.Level != "" && e.LevelTag != "" {
+		tags.Set(e.LevelTag, e.Level)
+	}
+	if e.Caller != "" && e.CallerTag != "" {
+		tags.Set(e.CallerTag, e.Caller)
+	}
+	return tags
+}
+
+// Sort keys of the line, such as the keys of AllTags(), in the tag order of
+// the Logger. See SetTagOrder().
+func (e *Entry) SortKeys(keys []string) {
+	e.order.sort(keys)
+}
+
+// Encodes log lines for output. Encoders must not modify the Entry or retain
+// it after returning.
+type Encoder interface {
+	// Append the encoded line to b without a trailing newline.
+	Encode(b []byte, e *Entry) ([]byte, error)
+}
+
+// Decodes log lines for the Parser.
+type Decoder interface {
+	// Decode a single line into tags. The timestamp, message, and caller are
+	// returned in the "timestamp", "msg", and "caller" tags. The timestamp is
+	// returned as written in the line.
+	Decode(line string, params *Params) (Tags, error)
+}
+
+// Adapter to allow the use of ordinary functions as Encoders.
+type EncoderFunc func(b []byte, e *Entry) ([]byte, error)
+
+// See Encoder.Encode
+func (f EncoderFunc) Encode(b []byte, e *Entry) ([]byte, error) {
+	return f(b, e)
+}
+
+// Adapter to allow the use of ordinary functions as Decoders.
+type DecoderFunc func(line string, params *Params) (Tags, error)
+
+// See Decoder.Decode
+func (f DecoderFunc) Decode(line string, params *Params) (Tags, error) {
+	return f(line, params)
+}
+
+// Adapter for the built-in Decoders, which also return the keys in the order
+// they appear in the line.
+type orderedDecoderFunc func(line string, params *Params) (Tags, []string, error)
+
+// See Decoder.Decode
+func (f orderedDecoderFunc) Decode(line string, params *Params) (Tags, error) {
+	tags, _, err := f(line, params)
+	return tags, err
+}
+
+type formatInfo struct {
+	name string
+	enc  Encoder
+	dec  Decoder
+}
+
+// Format IDs returned by RegisterFormat start here to leave room for
+// predefined formats.
+const firstCustomFormat = 1 << 10
+
+var (
+	formatsMu  sync.RWMutex
+	nextFormat = firstCustomFormat
+	formats    = map[int]*formatInfo{
+		FormatPlain:      {"plain", EncoderFunc(encodePlain), orderedDecoderFunc(decodePlain)},
+		FormatJSON:       {"json", EncoderFunc(encodeJSON), orderedDecoderFunc(decodeJSON)},
+		FormatLogfmt:     {"logfmt", EncoderFunc(encodeLogfmt), orderedDecoderFunc(decodeLogfmt)},
+		FormatSyslog5424: {"syslog5424", NewSyslogEncoder(SyslogOptions{}), nil},
+		FormatSyslog3164: {"syslog3164", NewSyslogEncoder(SyslogOptions{RFC3164: true}), nil},
+	}
+)
+
+// Register a custom log format. The returned format can be used with
+// SetFormat() and NewParser(), and ParseFormat() recognizes the name. The
+// Decoder may be nil if the format cannot be parsed. Registering a name again
+// replaces the Encoder and Decoder and returns the same format.
+func RegisterFormat(name string, enc Encoder, dec Decoder) int {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	name = strings.ToLower(name)
+	for format, info := range formats {
+		if info.name == name {
+			formats[format] = &formatInfo{name, enc, dec}
+			return format
+		}
+	}
+
+	format := nextFormat
+	nextFormat++
+	formats[format] = &formatInfo{name, enc, dec}
+	return format
+}
+
+func lookupFormat(format int) *formatInfo {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	return formats[format]
+}
+
+func lookupFormatName(name string) (int, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for format, info := range formats {
+		if info.name == name {
+			return format, true
+		}
+	}
+	return -1, false
+}
+
+// Get the name of a log format. An empty string is returned for unknown
+// formats.
+func FormatName(format int) string {
+	info := lookupFormat(format)
+	if info == nil {
+		return ""
+	}
+	return info.name
+}
+
+// Get the Encoder for a log format, or nil if the format is unknown.
+func FormatEncoder(format int) Encoder {
+	info := lookupFormat(format)
+	if info == nil {
+		return nil
+	}
+	return info.enc
+}
+
+// Get the Decoder for a log format, or nil if the format is unknown or cannot
+// be parsed.
+func FormatDecoder(format int) Decoder {
+	info := lookupFormat(format)
+	if info == nil {
+		return nil
+	}
+	return info.dec
+}
+
+// Encode a line in plain format: the prefix, timestamp, caller, tags in square
+// brackets in tag order, and the message. Tags and the message are escaped, see
+// escape.go. A stack trace follows as a block of lines indented with tabs.
+func encodePlain(b []byte, e *Entry) ([]byte, error) {
+	return appendPlainLine(b, e, true), nil
+}
+
+// Append a line in plain format. If block is not set, a stack trace is
+// escaped like the message so the result is a single line.
+func appendPlainLine(b []byte, e *Entry, block bool) []byte {
+	b = append(b, e.Params.Prefix...)
+	start := len(b)
+	sep := func() {
+		if len(b) > start {
+			b = append(b, ' ')
+		}
+	}
+
+	if e.Timestamp != "" {
+		b = append(b, e.Timestamp...)
+	}
+	if e.Caller != "" {
+		sep()
+		b = append(b, e.Caller...)
+		b = append(b, ':')
+	}
+
+	var frags *fragments
+	if e.cache != nil {
+		frags = e.cache.plainFragments()
+	} else {
+		frags = getFragments()
+		defer putFragments(frags)
+		frags.addPlainTags(e.Tags, &e.order)
+	}
+
+	// the level is merged into the sorted tags
+	var level []byte
+	if e.Level != "" && e.LevelTag != "" {
+		var buf [64]byte
+		level = appendPlainKey(buf[:0], e.LevelTag)
+		level = appendPlainTag(level, e.Level)
+		level = append(level, ']')
+	}
+	for i, f := range frags.list {
+		if f.key == e.LevelTag && e.Level != "" {
+			continue
+		}
+		if level != nil && frags.sortsBefore(e.LevelTag, level, i) {
+			sep()
+			b = append(b, level...)
+			level = nil
+		}
+		sep()
+		b = append(b, frags.bytes(i)...)
+	}
+	if level != nil {
+		sep()
+		b = append(b, level...)
+	}
+
+	if e.Message != "" {
+		sep()
+		b = appendPlainMessage(b, e.Message)
+	}
+
+	if frags.stack != "" {
+		if block {
+			for _, line := range strings.Split(string(frags.stack), "\n") {
+				b = append(b, '\n', '\t')
+				b = append(b, line...)
+			}
+		} else {
+			sep()
+			b = appendPlainEscaped(b, "\n"+string(frags.stack), plainMsgEscapes, false)
+		}
+	}
+	return b
+}
+
+// Encode a line in JSON format. The timestamp and message are written in the
+// "timestamp" and "msg" fields. Typed tag values are written natively.
+func encodeJSON(b []byte, e *Entry) ([]byte, error) {
+	var frags *fragments
+	if e.cache != nil {
+		var err error
+		frags, err = e.cache.jsonFragments()
+		if err != nil {
+			return b, err
+		}
+	} else {
+		frags = getFragments()
+		defer putFragments(frags)
+		err := frags.addJSONTags(e.Tags, &e.order)
+		if err != nil {
+			return b, err
+		}
+	}
+
+	// fields which are not tags replace tags with the same key
+	var fields [4]struct {
+		key   string
+		value string
+	}
+	n := 0
+	addField := func(key string, value string) {
+		for i := 0; i < n; i++ {
+			if fields[i].key == key {
+				fields[i].value = value
+				return
+			}
+		}
+		// insert in tag order
+		i := n
+		for i > 0 && e.order.less(key, fields[i-1].key) {
+			fields[i] = fields[i-1]
+			i--
+		}
+		fields[i].key = key
+		fields[i].value = value
+		n++
+	}
+	if e.Level != "" && e.LevelTag != "" {
+		addField(e.LevelTag, e.Level)
+	}
+	if e.Caller != "" && e.CallerTag != "" {
+		addField(e.CallerTag, e.Caller)
+	}
+	if e.Timestamp != "" {
+		addField("timestamp", e.Timestamp)
+	}
+	addField("msg", e.Message)
+
+	b = append(b, '{')
+	start := len(b)
+	comma := func() {
+		if len(b) > start {
+			b = append(b, ',')
+		}
+	}
+	appendField := func(i int) {
+		comma()
+		b = appendJSONString(b, fields[i].key)
+		b = append(b, ':')
+		b = appendJSONString(b, fields[i].value)
+	}
+
+	next := 0
+	for i, f := range frags.list {
+		for next < n && e.order.less(fields[next].key, f.key) {
+			appendField(next)
+			next++
+		}
+		if next < n && fields[next].key == f.key {
+			continue
+		}
+		comma()
+		b = append(b, frags.bytes(i)...)
+	}
+	for ; next < n; next++ {
+		appendField(next)
+	}
+	return append(b, '}'), nil
+}
+
+// Decode a line in JSON format. Numbers, booleans, and objects are returned as
+// typed values.
+func decodeJSON(line string, params *Params) (Tags, []string, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	mismatch := func(component string, err error) error {
+		offset := int(dec.InputOffset())
+		if se, ok := err.(*json.SyntaxError); ok {
+			offset = int(se.Offset)
+		}
+		return &ParseError{Offset: offset, Component: component, Err: err}
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, mismatch("object", err)
+	}
+	if tok != json.Delim('{') {
+		offset := len(line) - len(strings.TrimLeft(line, " \t\r\n"))
+		return nil, nil, &ParseError{Offset: offset, Component: "object"}
+	}
+
+	fields := make(map[string]interface{})
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, mismatch("key", err)
+		}
+		key := tok.(string)
+		var value interface{}
+		err = dec.Decode(&value)
+		if err != nil {
+			return nil, nil, mismatch("value", err)
+		}
+		if _, found := fields[key]; !found {
+			keys = append(keys, key)
+		}
+		fields[key] = value
+	}
+	_, err = dec.Token()
+	if err != nil {
+		return nil, nil, mismatch("object", err)
+	}
+
+	return tagValueMap(fields), keys, nil
+}
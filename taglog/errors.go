@@ -0,0 +1,246 @@
+package taglog
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Keys of the tags written by LogError()
+const (
+	ErrorTag      = "error"       // message of the error
+	ErrorTypeTag  = "error_type"  // Go type of the error
+	ErrorChainTag = "error_chain" // type and message of each wrapped error
+	StackTag      = "stack"       // stack trace, see SetStackLevel()
+)
+
+// The maximum number of frames in a stack trace.
+const maxStackFrames = 32
+
+// A stack trace with a "function\n\tfile:line" pair of lines for each frame.
+// In plain format it is written as an indented block after the line.
+type stackTrace string
+
+// An error with tags which are added to any line which logs it, or an error
+// wrapping it. See TagError().
+type TaggedError struct {
+	Err  error
+	Tags Tags
+}
+
+func (e *TaggedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TaggedError) Unwrap() error {
+	return e.Err
+}
+
+// Attach tags given as alternating keys and values, as for Printw(), to an
+// error. The error can be wrapped further, e.g. with fmt.Errorf("%w"), and the
+// tags are added to lines which log it with the print methods or LogError(). A
+// nil error returns nil.
+func TagError(err error, kv ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &TaggedError{Err: err, Tags: kvTags(kv)}
+}
+
+// Collect the tags attached with TagError() to an error and the errors it
+// wraps. Tags from outer errors replace tags from the errors they wrap. nil is
+// returned if there are none.
+func ErrorTags(err error) Tags {
+	var layers []Tags
+	walkErrors(err, func(err error) {
+		if te, ok := err.(*TaggedError); ok && len(te.Tags) > 0 {
+			layers = append(layers, te.Tags)
+		}
+	})
+	if layers == nil {
+		return nil
+	}
+
+	tags := make(Tags)
+	for i := len(layers) - 1; i >= 0; i-- {
+		layerTags(tags, layers[i].Copy())
+	}
+	return tags
+}
+
+// Call fn for an error and the errors it wraps, found with errors.Unwrap() or
+// the Unwrap() []error method used by errors.Join(), depth first.
+func walkErrors(err error, fn func(err error)) {
+	if err == nil {
+		return
+	}
+	fn(err)
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		walkErrors(u.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, next := range u.Unwrap() {
+			walkErrors(next, fn)
+		}
+	}
+}
+
+// Get the tags attached to the errors among the arguments of a print method,
+// or nil if there are none.
+func argTags(v []interface{}) Tags {
+	var tags Tags
+	for _, arg := range v {
+		if err, ok := arg.(error); ok {
+			if et := ErrorTags(err); et != nil {
+				if tags == nil {
+					tags = et
+				} else {
+					layerTags(tags, et)
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// Get the tags for an error: its message, its type, the type and message of
+// the errors it wraps, and the tags attached to any of them. TaggedErrors are
+// skipped when determining the types.
+func errorTags(err error) Tags {
+	tags := ErrorTags(err)
+	if tags == nil {
+		tags = make(Tags, 4)
+	}
+	tags[ErrorTag] = err.Error()
+
+	var typ string
+	var chain []string
+	walkErrors(err, func(next error) {
+		if _, ok := next.(*TaggedError); ok {
+			return
+		}
+		if typ == "" {
+			typ = fmt.Sprintf("%T", next)
+			return
+		}
+		chain = append(chain, fmt.Sprintf("%T: %v", next, next))
+	})
+	tags[ErrorTypeTag] = typ
+	if chain != nil {
+		tags[ErrorChainTag] = chain
+	}
+	return tags
+}
+
+// Capture the stack of the calling goroutine. skip is the number of frames to
+// skip, where 1 identifies the caller of captureStack.
+func captureStack(skip int) stackTrace {
+	var pcs [maxStackFrames]uintptr
+	n := runtime.Callers(skip+1, pcs[:])
+	if n == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return stackTrace(b.String())
+}
+
+// Set the minimum level at which LogError() captures a stack trace. An empty
+// string, the default, disables stack traces.
+func (this *Logger) SetStackLevel(lvl string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.stackLevel = lvl
+}
+
+// Check whether LogError() captures a stack trace for a level, and get the
+// number of additional frames to skip.
+func (this *Logger) wantStack(level string) (bool, int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.stackLevel == "" || level == "" || this.levelset == nil {
+		return false, 0
+	}
+	return !this.levelset.Less(level, this.stackLevel), this.callerSkip
+}
+
+// Log an error at a level with a message. The message, type, and wrapped
+// errors of err are written in the "error", "error_type", and "error_chain"
+// tags, which are typed fields in JSON format. If the level is at least the
+// level set with SetStackLevel(), the stack of the caller is written in the
+// "stack" tag, which is an indented block after the line in plain format.
+// Nothing is written for a nil error.
+func (this *Logger) LogError(level string, err error, msg string) {
+	if err == nil || !this.Enabled(level) {
+		return
+	}
+	tags := errorTags(err)
+	if want, skip := this.wantStack(level); want {
+		tags[StackTag] = captureStack(skip + 2)
+	}
+	this.output(2, level, msg, tags)
+}
+
+func (mlog *MultiLogger) SetStackLevel(lvl string) {
+	for _, logger := range mlog.loggers {
+		logger.SetStackLevel(lvl)
+	}
+}
+
+// Check whether any of the loggers captures a stack trace for a level.
+func (mlog *MultiLogger) wantStack(level string) (bool, int) {
+	for _, logger := range mlog.loggers {
+		if ws, ok := logger.(interface{ wantStack(string) (bool, int) }); ok {
+			if want, _ := ws.wantStack(level); want {
+				return true, mlog.CallerSkip()
+			}
+		}
+	}
+	return false, 0
+}
+
+// The stack is captured once if any of the loggers captures stack traces for
+// the level, and written by all of them.
+func (mlog *MultiLogger) LogError(level string, err error, msg string) {
+	if err == nil || !mlog.Enabled(level) {
+		return
+	}
+	tags := errorTags(err)
+	if want, skip := mlog.wantStack(level); want {
+		tags[StackTag] = captureStack(skip + 2)
+	}
+	mlog.LoutputDepthCtx(2, nil, level, msg, tags)
+}
+
+// Set the minimum level at which the Standard Logger captures stack traces.
+func SetStackLevel(lvl string) {
+	std.SetStackLevel(lvl)
+}
+
+// Log an error using the Standard Logger. See Logger.LogError().
+func LogError(level string, err error, msg string) {
+	if err == nil || !std.Enabled(level) {
+		return
+	}
+	tags := errorTags(err)
+	if want, skip := std.wantStack(level); want {
+		tags[StackTag] = captureStack(skip + 2)
+	}
+	std.output(2, level, msg, tags)
+}
@@ -0,0 +1,135 @@
+package taglog
+
+import (
+	"strings"
+	"time"
+)
+
+// Escaping in plain format. Tag keys and values escape the characters which
+// delimit tags, and a message escapes a leading "[" so it is not read as a tag.
+// Newlines and carriage returns are written as \n and \r so the tags and
+// message of a log line are on a single line. A backslash is only escaped when it would otherwise be read as
+// the start of an escape sequence, so most text is written unchanged.
+
+// Characters which follow a backslash in an escape sequence.
+const (
+	plainTagEscapes = `\[]=,nr`
+	plainMsgEscapes = `\[nr`
+)
+
+// Append a tag key or value in plain format.
+func appendPlainTag(b []byte, s string) []byte {
+	if strings.IndexAny(s, "\\[]=,\n\r") < 0 {
+		return append(b, s...)
+	}
+	return appendPlainEscaped(b, s, plainTagEscapes, true)
+}
+
+// Append a tag value in plain format. Multiple values are separated by commas.
+func appendPlainValue(b []byte, v interface{}) []byte {
+	switch vs := resolveTagValue(v).(type) {
+	case string:
+		return appendPlainTag(b, vs)
+	case []string:
+		for i, s := range vs {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendPlainTag(b, s)
+		}
+		return b
+	case nil, int64, float64, bool, time.Time:
+		return appendTagValue(b, vs)
+	default:
+		return appendPlainTag(b, formatTagValue(vs))
+	}
+}
+
+// Append a message in plain format.
+func appendPlainMessage(b []byte, s string) []byte {
+	if strings.HasPrefix(s, "[") {
+		b = append(b, '\\')
+	}
+	if strings.IndexAny(s, "\\\n\r") < 0 {
+		return append(b, s...)
+	}
+	return appendPlainEscaped(b, s, plainMsgEscapes, false)
+}
+
+// Append a string with escapes. In tags, the delimiters are escaped, and a
+// trailing backslash is escaped since it is followed by a delimiter.
+func appendPlainEscaped(b []byte, s string, escapes string, tag bool) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\n':
+			b = append(b, '\\', 'n')
+		case c == '\r':
+			b = append(b, '\\', 'r')
+		case c == '\\':
+			if i+1 == len(s) && tag {
+				b = append(b, '\\', '\\')
+			} else if i+1 < len(s) && (s[i+1] == '\n' || s[i+1] == '\r' || strings.IndexByte(escapes, s[i+1]) >= 0) {
+				b = append(b, '\\', '\\')
+			} else {
+				b = append(b, '\\')
+			}
+		case tag && strings.IndexByte(`[]=,`, c) >= 0:
+			b = append(b, '\\', c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// Remove the escapes from a tag key or value, or a message, in plain format. A
+// backslash which does not start an escape sequence is kept.
+func unescapePlain(s string, escapes string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) && strings.IndexByte(escapes, s[i+1]) >= 0 {
+			i++
+			switch s[i] {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			default:
+				c = s[i]
+			}
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+// Find the first occurrence of c in a tag which is not escaped, or -1.
+func indexPlainTag(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(plainTagEscapes, s[i+1]) >= 0 {
+			i++
+		} else if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// Split the values of a tag at commas which are not escaped, and remove the
+// escapes.
+func splitPlainValues(s string) []string {
+	var values []string
+	for {
+		i := indexPlainTag(s, ',')
+		if i < 0 {
+			return append(values, unescapePlain(s, plainTagEscapes))
+		}
+		values = append(values, unescapePlain(s[:i], plainTagEscapes))
+		s = s[i+1:]
+	}
+}
@@ -0,0 +1,61 @@
+package taglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Keys which the Parser treats specially, so they do not round trip as
+// ordinary tags.
+var reservedKeys = map[string]bool{
+	"":            true,
+	"tags":        true,
+	"msg":         true,
+	"level":       true,
+	"timestamp":   true,
+	"caller":      true,
+	StackTag:      true,
+	ErrorTag:      true,
+	ErrorTypeTag:  true,
+	ErrorChainTag: true,
+}
+
+// Write a tag and a message in plain format and check that the Parser reads
+// back the same values.
+func FuzzPlainRoundTrip(f *testing.F) {
+	f.Add("k", "v", "message")
+	f.Add("path", `a=b,c]d[e\`, `[not a tag] \n \\ \`)
+	f.Add("key\nwith\rbreaks", "value\\", "multi\nline\rmessage\\")
+	f.Add("a,b", "x\\=y", "")
+	f.Add("k", "", "\t leading tab")
+
+	f.Fuzz(func(t *testing.T, key string, value string, msg string) {
+		if reservedKeys[key] {
+			t.Skip()
+		}
+
+		var buf bytes.Buffer
+		logger := New(&buf, "", 0)
+		logger.Printw(msg, key, value)
+		if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+			t.Fatalf("line is not a single line: %q", buf.String())
+		}
+
+		p := NewParser(Params{Format: FormatPlain})
+		p.SetStrict(true)
+		p.SetInput(&buf)
+		rec, err := p.Next()
+		if err != nil {
+			t.Fatalf("parsing %q: %v", buf.String(), err)
+		}
+		if rec.Message != msg {
+			t.Errorf("message: got %q, want %q from %q", rec.Message, msg, buf.String())
+		}
+		if got := rec.Tags.GetAll(key); len(got) != 1 || got[0] != value {
+			t.Errorf("tag %q: got %q, want %q from %q", key, got, value, buf.String())
+		}
+		if len(rec.Tags) != 1 {
+			t.Errorf("tags: got %v, want only %q from %q", rec.Tags, key, buf.String())
+		}
+	})
+}
@@ -0,0 +1,309 @@
+package taglog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options for how a MultiLogger writes to its loggers. See SetFanOut.
+type FanOutOptions struct {
+	Parallel         bool                     // write to the loggers concurrently
+	Timeout          time.Duration            // with Parallel, stop waiting for a logger after this long, 0 waits
+	Timeouts         map[string]time.Duration // per named logger, overrides Timeout
+	FailureThreshold int                      // consecutive failures which disable a logger, 0 never disables
+	Cooldown         time.Duration            // how long a logger stays disabled, defaults to 30 seconds
+	OnDisable        func(err *ChildError)    // called when a logger is disabled
+}
+
+// Returned for a logger which did not finish writing a line within the
+// timeout. The write continues in the background.
+var ErrTimeout = fmt.Errorf("Write timed out")
+
+// Returned for a logger whose previous write timed out and has not finished.
+var ErrStalled = fmt.Errorf("Previous write has not finished")
+
+// An error from one of the loggers of a MultiLogger. Errors from several
+// loggers are combined with errors.Join.
+type ChildError struct {
+	Index    int    // position of the logger
+	Name     string // name of the logger, empty if unnamed
+	Err      error
+	Disabled bool // the logger was disabled because of this error
+}
+
+func (e *ChildError) Error() string {
+	s := "Logger " + childID(e.Index, e.Name) + ": " + e.Err.Error()
+	if e.Disabled {
+		s += " (disabled)"
+	}
+	return s
+}
+
+func (e *ChildError) Unwrap() error {
+	return e.Err
+}
+
+// Identify a logger by name, or by position if it is unnamed.
+func childID(index int, name string) string {
+	if name != "" {
+		return strconv.Quote(name)
+	}
+	return "#" + strconv.Itoa(index)
+}
+
+// Circuit breaker and timeout state of a logger in a MultiLogger.
+type childState struct {
+	mu       sync.Mutex
+	failures int       // consecutive failures
+	until    time.Time // disabled until this time
+	probing  bool      // the cooldown has passed, one more failure disables it again
+	stalled  atomic.Int32
+}
+
+func newChildStates(n int) []*childState {
+	states := make([]*childState, n)
+	for i := range states {
+		states[i] = new(childState)
+	}
+	return states
+}
+
+func (st *childState) disabled(now time.Time) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return now.Before(st.until)
+}
+
+// Implemented by loggers which can write a line for a time and program counter
+// determined in advance, so the caller is reported correctly when writing from
+// another goroutine.
+type pcOutputter interface {
+	outputPC(now time.Time, pc uintptr, ctx context.Context, level string, standard bool, s string, tags Tags) error
+}
+
+// Write a log line for a given time and program counter. If standard is set,
+// the standard level of the Logger is used instead of level.
+func (this *Logger) outputPC(now time.Time, pc uintptr, ctx context.Context, level string, standard bool, s string, tags Tags) error {
+	if standard {
+		level = this.standardLevel
+	}
+	return this.write(ctx, now, pc, level, s, this.contextTags(ctx, tags))
+}
+
+// Set how lines are written to the loggers. By default they are written one
+// after another, and the errors of all loggers are returned. With Parallel,
+// they are written concurrently and a logger which does not finish within its
+// timeout is reported with ErrTimeout; the caller is determined with the
+// CallerSkip() of the MultiLogger. A logger which fails FailureThreshold
+// times in a row is disabled for the cooldown, and disabled again by its first
+// failure after that. It should be set before the MultiLogger is used.
+func (mlog *MultiLogger) SetFanOut(opts FanOutOptions) {
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	mlog.opts = opts
+}
+
+// Get the loggers which are currently disabled, identified by name, or by
+// position as "#2" if they are unnamed.
+func (mlog *MultiLogger) Disabled() []string {
+	var ids []string
+	now := time.Now()
+	for i, st := range mlog.states {
+		if st.disabled(now) {
+			ids = append(ids, childID(i, mlog.names[i]))
+		}
+	}
+	return ids
+}
+
+// Check whether a line should be written to a logger. If standard is set, the
+// standard level of the logger is used instead of level.
+func (mlog *MultiLogger) selected(i int, now time.Time, ctx context.Context, level string, standard bool, tags Tags) bool {
+	if mlog.states[i].disabled(now) {
+		return false
+	}
+	if standard {
+		level = standardLevelOf(mlog.loggers[i])
+	}
+	return mlog.routes[i].allows(mlog.loggers[i], ctx, level, tags)
+}
+
+// Same as selected, but only the level and whether the logger is disabled are
+// checked. The tag predicates of the route are left to selected, since the
+// tags of a line are not known yet.
+func (mlog *MultiLogger) selectedLevel(i int, now time.Time, level string, standard bool) bool {
+	if mlog.states[i].disabled(now) {
+		return false
+	}
+	if standard {
+		level = standardLevelOf(mlog.loggers[i])
+	}
+	return mlog.routes[i].allowsLevel(level)
+}
+
+// Get the standard level of a logger, or an empty string if it is not known.
+// A MultiLogger reports the standard level of its first logger.
+func standardLevelOf(logger Interface) string {
+	switch l := logger.(type) {
+	case *Logger:
+		return l.standardLevel
+	case *MultiLogger:
+		if len(l.loggers) > 0 {
+			return standardLevelOf(l.loggers[0])
+		}
+	}
+	return ""
+}
+
+// Record the result of a write for the circuit breaker and identify the
+// logger in the error.
+func (mlog *MultiLogger) finish(i int, err error) error {
+	st := mlog.states[i]
+	if err == nil {
+		st.mu.Lock()
+		st.failures = 0
+		st.probing = false
+		st.mu.Unlock()
+		return nil
+	}
+
+	cerr := &ChildError{Index: i, Name: mlog.names[i], Err: err}
+	if mlog.opts.FailureThreshold <= 0 {
+		return cerr
+	}
+
+	st.mu.Lock()
+	st.failures++
+	if st.probing || st.failures >= mlog.opts.FailureThreshold {
+		st.failures = 0
+		st.probing = true
+		st.until = time.Now().Add(mlog.opts.Cooldown)
+		cerr.Disabled = true
+	}
+	st.mu.Unlock()
+
+	if cerr.Disabled && mlog.opts.OnDisable != nil {
+		mlog.opts.OnDisable(cerr)
+	}
+	return cerr
+}
+
+// Write a line to all selected loggers. calldepth is relative to the caller of
+// fanOut. If standard is set, each Logger uses its standard level.
+func (mlog *MultiLogger) fanOut(calldepth int, ctx context.Context, level string, standard bool, s string, tags Tags) error {
+	now := time.Now()
+
+	if mlog.opts.Parallel {
+		var pc uintptr
+		var pcs [1]uintptr
+		if runtime.Callers(calldepth+mlog.CallerSkip()+1, pcs[:]) > 0 {
+			pc = pcs[0]
+		}
+		return mlog.outputPC(now, pc, ctx, level, standard, s, tags)
+	}
+
+	errs := make([]error, len(mlog.loggers))
+	for i, logger := range mlog.loggers {
+		if !mlog.selected(i, now, ctx, level, standard, tags) {
+			continue
+		}
+		var err error
+		switch {
+		case standard && ctx == nil && tags == nil:
+			err = logger.OutputDepth(calldepth+1, s)
+		case standard:
+			err = logger.OutputDepthCtx(calldepth+1, ctx, s, tags)
+		case ctx == nil && tags == nil:
+			err = logger.LoutputDepth(calldepth+1, level, s)
+		default:
+			err = logger.LoutputDepthCtx(calldepth+1, ctx, level, s, tags)
+		}
+		errs[i] = mlog.finish(i, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Write a line to all selected loggers for a given time and program counter.
+// See pcOutputter.
+func (mlog *MultiLogger) outputPC(now time.Time, pc uintptr, ctx context.Context, level string, standard bool, s string, tags Tags) error {
+	errs := make([]error, len(mlog.loggers))
+
+	if !mlog.opts.Parallel {
+		for i, logger := range mlog.loggers {
+			if mlog.selected(i, now, ctx, level, standard, tags) {
+				errs[i] = mlog.finish(i, writePC(logger, now, pc, ctx, level, standard, s, tags))
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	var wg sync.WaitGroup
+	for i, logger := range mlog.loggers {
+		if !mlog.selected(i, now, ctx, level, standard, tags) {
+			continue
+		}
+		timeout := mlog.opts.Timeout
+		if t, found := mlog.opts.Timeouts[mlog.names[i]]; found && mlog.names[i] != "" {
+			timeout = t
+		}
+		wg.Add(1)
+		go func(i int, logger Interface) {
+			defer wg.Done()
+			err := mlog.writeTimeout(mlog.states[i], timeout, func() error {
+				return writePC(logger, now, pc, ctx, level, standard, s, tags)
+			})
+			errs[i] = mlog.finish(i, err)
+		}(i, logger)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Write a line to any implementation of Interface. Implementations which are
+// not a pcOutputter report their own caller.
+func writePC(logger Interface, now time.Time, pc uintptr, ctx context.Context, level string, standard bool, s string, tags Tags) error {
+	if pco, ok := logger.(pcOutputter); ok {
+		return pco.outputPC(now, pc, ctx, level, standard, s, tags)
+	}
+	if standard {
+		return logger.OutputDepthCtx(1, ctx, s, tags)
+	}
+	return logger.LoutputDepthCtx(1, ctx, level, s, tags)
+}
+
+// Run a write, giving up after the timeout. A write which times out keeps the
+// logger stalled until it finishes.
+func (mlog *MultiLogger) writeTimeout(st *childState, timeout time.Duration, write func() error) error {
+	if timeout <= 0 {
+		return write()
+	}
+	if st.stalled.Load() > 0 {
+		return ErrStalled
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- write()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		st.stalled.Add(1)
+		go func() {
+			<-done
+			st.stalled.Add(-1)
+		}()
+		return ErrTimeout
+	}
+}
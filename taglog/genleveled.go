@@ -0,0 +1,117 @@
+//go:build ignore
+
+// Generates leveled.go, which contains level-specific methods for each level
+// of DefaultLevelSet. Run with "go generate".
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/vimeo/go-taglog/taglog"
+)
+
+var tmpl = template.Must(template.New("leveled").Parse(`// Code generated by genleveled.go. DO NOT EDIT.
+
+package taglog
+
+import (
+	"fmt"
+)
+
+// Leveled output, implemented by Logger and MultiLogger.
+type Leveled interface {
+	Enabled(level string) bool
+	Lprintf(level string, format string, v ...interface{})
+	Lprint(level string, v ...interface{})
+{{- range .}}
+	{{.Name}}f(format string, v ...interface{})
+	{{.Name}}(v ...interface{})
+{{- end}}
+}
+
+var (
+	_ Leveled = (*Logger)(nil)
+	_ Leveled = (*MultiLogger)(nil)
+)
+{{range .}}
+// Print a message at the {{.Level}} level. See Lprintf().
+func (this *Logger) {{.Name}}f(format string, v ...interface{}) {
+	if !this.Enabled({{.Const}}) {
+		return
+	}
+	this.output(2, {{.Const}}, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the {{.Level}} level. See Lprint().
+func (this *Logger) {{.Name}}(v ...interface{}) {
+	if !this.Enabled({{.Const}}) {
+		return
+	}
+	this.output(2, {{.Const}}, fmt.Sprint(v...), argTags(v))
+}
+{{end}}
+{{- range .}}
+func (mlog *MultiLogger) {{.Name}}f(format string, v ...interface{}) {
+	if !mlog.Enabled({{.Const}}) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, {{.Const}}, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) {{.Name}}(v ...interface{}) {
+	if !mlog.Enabled({{.Const}}) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, {{.Const}}, fmt.Sprint(v...), argTags(v))
+}
+{{end}}
+{{- range .}}
+// Print a message at the {{.Level}} level using the Standard Logger.
+func {{.Name}}f(format string, v ...interface{}) {
+	if !std.Enabled({{.Const}}) {
+		return
+	}
+	std.output(2, {{.Const}}, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the {{.Level}} level using the Standard Logger.
+func {{.Name}}(v ...interface{}) {
+	if !std.Enabled({{.Const}}) {
+		return
+	}
+	std.output(2, {{.Const}}, fmt.Sprint(v...), argTags(v))
+}
+{{end}}`))
+
+type level struct {
+	Level string // level name, e.g. "WARNING"
+	Name  string // method name, e.g. "Warning"
+	Const string // name of the level constant, e.g. "LevelWarning"
+}
+
+func main() {
+	var levels []level
+	for _, lvl := range taglog.DefaultLevelSet.Levels() {
+		name := strings.ToUpper(lvl[:1]) + strings.ToLower(lvl[1:])
+		levels = append(levels, level{lvl, name, "Level" + name})
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, levels)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = os.WriteFile("leveled.go", src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
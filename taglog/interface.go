@@ -0,0 +1,135 @@
+package taglog
+
+import (
+	"context"
+	"io"
+)
+
+// The methods shared by Logger and MultiLogger. Functions which log should
+// accept an Interface so they can be given either. A MultiLogger can contain
+// any implementation, including other MultiLoggers.
+type Interface interface {
+	Leveled
+
+	// Params
+	Params() Params
+	SetFlags(flag int)
+	Flags() int
+	SetPrefix(prefix string)
+	Prefix() string
+	SetTimestampFormatType(tsFormatType int)
+	TimestampFormatType() int
+	SetTimestampFormat(tsFormat string)
+	TimestampFormat() string
+	SetFormat(format int)
+	Format() int
+	SetCallerTag(tag string)
+	SetCallerSkip(skip int)
+	CallerSkip() int
+	SetOutput(w io.Writer)
+	GetOutput() io.Writer
+
+	// Levels
+	DefineLevels(ls *LevelSet)
+	SetLevel(lvl string)
+	GetLevel() string
+	SetLevelTag(tag string)
+	SetStandardLevel(lvl string)
+	SetStackLevel(lvl string)
+
+	// Tags
+	AddTag(key string, value ...string)
+	MergeTag(key string, value ...string)
+	PushTag(key string, value ...string)
+	PopTag(key string)
+	SetTag(key string, value ...string)
+	SetTagValue(key string, value interface{})
+	GetTagValue(key string) interface{}
+	GetTag(key string) string
+	GetTags(key string) []string
+	DelTag(key string)
+	DelTags()
+	ExportTags() map[string][]string
+	ImportTags(tags map[string][]string)
+	ParseTags(tags []string)
+	SetTagOrder(order int, priority ...string)
+	AddContextExtractor(fn ContextExtractor)
+
+	// Output
+	Output(s string) error
+	Loutput(level string, s string) error
+	OutputDepth(calldepth int, s string) error
+	LoutputDepth(calldepth int, level string, s string) error
+	OutputDepthCtx(calldepth int, ctx context.Context, s string, tags Tags) error
+	LoutputDepthCtx(calldepth int, ctx context.Context, level string, s string, tags Tags) error
+	Printf(format string, v ...interface{})
+	Print(v ...interface{})
+	Println(v ...interface{})
+	Lprintln(level string, v ...interface{})
+	Printw(msg string, kv ...interface{})
+	Lprintw(level string, msg string, kv ...interface{})
+	PrintfCtx(ctx context.Context, format string, v ...interface{})
+	PrintCtx(ctx context.Context, v ...interface{})
+	LprintfCtx(ctx context.Context, level string, format string, v ...interface{})
+	LprintCtx(ctx context.Context, level string, v ...interface{})
+	LprintwCtx(ctx context.Context, level string, msg string, kv ...interface{})
+	LogError(level string, err error, msg string)
+	Fatal(v ...interface{})
+	Fatalf(format string, v ...interface{})
+	Fatalln(v ...interface{})
+	Lfatal(level string, v ...interface{})
+	Lfatalf(level string, format string, v ...interface{})
+	Lfatalln(level string, v ...interface{})
+	Panic(v ...interface{})
+	Panicf(format string, v ...interface{})
+	Panicln(v ...interface{})
+
+	// Asynchronous output
+	SetAsync(opts AsyncOptions)
+	Flush() error
+	Close() error
+	Dropped() uint64
+}
+
+var (
+	_ Interface = (*Logger)(nil)
+	_ Interface = (*MultiLogger)(nil)
+)
+
+// Implementations of Interface other than Logger and MultiLogger can implement
+// these to support MultiLogger.Copy(), With(), and WithTags(). Otherwise the
+// same instance is used by the new MultiLogger.
+type (
+	copier interface {
+		Copy() Interface
+	}
+	tagsWither interface {
+		WithTags(tags Tags) Interface
+	}
+)
+
+// Copy any implementation of Interface. See Logger.Copy().
+func copyInterface(logger Interface) Interface {
+	switch l := logger.(type) {
+	case *Logger:
+		return l.Copy()
+	case *MultiLogger:
+		return l.Copy()
+	case copier:
+		return l.Copy()
+	}
+	return logger
+}
+
+// Create a child of any implementation of Interface. See Logger.WithTags().
+func withTagsInterface(logger Interface, tags Tags) Interface {
+	switch l := logger.(type) {
+	case *Logger:
+		return l.WithTags(tags)
+	case *MultiLogger:
+		return l.WithTags(tags)
+	case tagsWither:
+		return l.WithTags(tags)
+	}
+	return logger
+}
@@ -0,0 +1,403 @@
+// Code generated by genleveled.go. DO NOT EDIT.
+
+package taglog
+
+import (
+	"fmt"
+)
+
+// Leveled output, implemented by Logger and MultiLogger.
+type Leveled interface {
+	Enabled(level string) bool
+	Lprintf(level string, format string, v ...interface{})
+	Lprint(level string, v ...interface{})
+	Debugf(format string, v ...interface{})
+	Debug(v ...interface{})
+	Infof(format string, v ...interface{})
+	Info(v ...interface{})
+	Noticef(format string, v ...interface{})
+	Notice(v ...interface{})
+	Warningf(format string, v ...interface{})
+	Warning(v ...interface{})
+	Errorf(format string, v ...interface{})
+	Error(v ...interface{})
+	Criticalf(format string, v ...interface{})
+	Critical(v ...interface{})
+	Alertf(format string, v ...interface{})
+	Alert(v ...interface{})
+	Emergencyf(format string, v ...interface{})
+	Emergency(v ...interface{})
+}
+
+var (
+	_ Leveled = (*Logger)(nil)
+	_ Leveled = (*MultiLogger)(nil)
+)
+
+// Print a message at the DEBUG level. See Lprintf().
+func (this *Logger) Debugf(format string, v ...interface{}) {
+	if !this.Enabled(LevelDebug) {
+		return
+	}
+	this.output(2, LevelDebug, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the DEBUG level. See Lprint().
+func (this *Logger) Debug(v ...interface{}) {
+	if !this.Enabled(LevelDebug) {
+		return
+	}
+	this.output(2, LevelDebug, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the INFO level. See Lprintf().
+func (this *Logger) Infof(format string, v ...interface{}) {
+	if !this.Enabled(LevelInfo) {
+		return
+	}
+	this.output(2, LevelInfo, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the INFO level. See Lprint().
+func (this *Logger) Info(v ...interface{}) {
+	if !this.Enabled(LevelInfo) {
+		return
+	}
+	this.output(2, LevelInfo, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the NOTICE level. See Lprintf().
+func (this *Logger) Noticef(format string, v ...interface{}) {
+	if !this.Enabled(LevelNotice) {
+		return
+	}
+	this.output(2, LevelNotice, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the NOTICE level. See Lprint().
+func (this *Logger) Notice(v ...interface{}) {
+	if !this.Enabled(LevelNotice) {
+		return
+	}
+	this.output(2, LevelNotice, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the WARNING level. See Lprintf().
+func (this *Logger) Warningf(format string, v ...interface{}) {
+	if !this.Enabled(LevelWarning) {
+		return
+	}
+	this.output(2, LevelWarning, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the WARNING level. See Lprint().
+func (this *Logger) Warning(v ...interface{}) {
+	if !this.Enabled(LevelWarning) {
+		return
+	}
+	this.output(2, LevelWarning, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the ERROR level. See Lprintf().
+func (this *Logger) Errorf(format string, v ...interface{}) {
+	if !this.Enabled(LevelError) {
+		return
+	}
+	this.output(2, LevelError, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the ERROR level. See Lprint().
+func (this *Logger) Error(v ...interface{}) {
+	if !this.Enabled(LevelError) {
+		return
+	}
+	this.output(2, LevelError, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the CRITICAL level. See Lprintf().
+func (this *Logger) Criticalf(format string, v ...interface{}) {
+	if !this.Enabled(LevelCritical) {
+		return
+	}
+	this.output(2, LevelCritical, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the CRITICAL level. See Lprint().
+func (this *Logger) Critical(v ...interface{}) {
+	if !this.Enabled(LevelCritical) {
+		return
+	}
+	this.output(2, LevelCritical, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the ALERT level. See Lprintf().
+func (this *Logger) Alertf(format string, v ...interface{}) {
+	if !this.Enabled(LevelAlert) {
+		return
+	}
+	this.output(2, LevelAlert, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the ALERT level. See Lprint().
+func (this *Logger) Alert(v ...interface{}) {
+	if !this.Enabled(LevelAlert) {
+		return
+	}
+	this.output(2, LevelAlert, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the EMERGENCY level. See Lprintf().
+func (this *Logger) Emergencyf(format string, v ...interface{}) {
+	if !this.Enabled(LevelEmergency) {
+		return
+	}
+	this.output(2, LevelEmergency, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the EMERGENCY level. See Lprint().
+func (this *Logger) Emergency(v ...interface{}) {
+	if !this.Enabled(LevelEmergency) {
+		return
+	}
+	this.output(2, LevelEmergency, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Debugf(format string, v ...interface{}) {
+	if !mlog.Enabled(LevelDebug) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelDebug, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Debug(v ...interface{}) {
+	if !mlog.Enabled(LevelDebug) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelDebug, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Infof(format string, v ...interface{}) {
+	if !mlog.Enabled(LevelInfo) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelInfo, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Info(v ...interface{}) {
+	if !mlog.Enabled(LevelInfo) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelInfo, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Noticef(format string, v ...interface{}) {
+	if !mlog.Enabled(LevelNotice) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelNotice, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Notice(v ...interface{}) {
+	if !mlog.Enabled(LevelNotice) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelNotice, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Warningf(format string, v ...interface{}) {
+	if !mlog.Enabled(LevelWarning) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelWarning, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Warning(v ...interface{}) {
+	if !mlog.Enabled(LevelWarning) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelWarning, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Errorf(format string, v ...interface{}) {
+	if !mlog.Enabled(LevelError) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelError, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Error(v ...interface{}) {
+	if !mlog.Enabled(LevelError) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelError, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Criticalf(format string, v ...interface{}) {
+	if !mlog.Enabled(LevelCritical) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelCritical, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Critical(v ...interface{}) {
+	if !mlog.Enabled(LevelCritical) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelCritical, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Alertf(format string, v ...interface{}) {
+	if !mlog.Enabled(LevelAlert) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelAlert, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Alert(v ...interface{}) {
+	if !mlog.Enabled(LevelAlert) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelAlert, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Emergencyf(format string, v ...interface{}) {
+	if !mlog.Enabled(LevelEmergency) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelEmergency, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Emergency(v ...interface{}) {
+	if !mlog.Enabled(LevelEmergency) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, LevelEmergency, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the DEBUG level using the Standard Logger.
+func Debugf(format string, v ...interface{}) {
+	if !std.Enabled(LevelDebug) {
+		return
+	}
+	std.output(2, LevelDebug, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the DEBUG level using the Standard Logger.
+func Debug(v ...interface{}) {
+	if !std.Enabled(LevelDebug) {
+		return
+	}
+	std.output(2, LevelDebug, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the INFO level using the Standard Logger.
+func Infof(format string, v ...interface{}) {
+	if !std.Enabled(LevelInfo) {
+		return
+	}
+	std.output(2, LevelInfo, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the INFO level using the Standard Logger.
+func Info(v ...interface{}) {
+	if !std.Enabled(LevelInfo) {
+		return
+	}
+	std.output(2, LevelInfo, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the NOTICE level using the Standard Logger.
+func Noticef(format string, v ...interface{}) {
+	if !std.Enabled(LevelNotice) {
+		return
+	}
+	std.output(2, LevelNotice, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the NOTICE level using the Standard Logger.
+func Notice(v ...interface{}) {
+	if !std.Enabled(LevelNotice) {
+		return
+	}
+	std.output(2, LevelNotice, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the WARNING level using the Standard Logger.
+func Warningf(format string, v ...interface{}) {
+	if !std.Enabled(LevelWarning) {
+		return
+	}
+	std.output(2, LevelWarning, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the WARNING level using the Standard Logger.
+func Warning(v ...interface{}) {
+	if !std.Enabled(LevelWarning) {
+		return
+	}
+	std.output(2, LevelWarning, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the ERROR level using the Standard Logger.
+func Errorf(format string, v ...interface{}) {
+	if !std.Enabled(LevelError) {
+		return
+	}
+	std.output(2, LevelError, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the ERROR level using the Standard Logger.
+func Error(v ...interface{}) {
+	if !std.Enabled(LevelError) {
+		return
+	}
+	std.output(2, LevelError, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the CRITICAL level using the Standard Logger.
+func Criticalf(format string, v ...interface{}) {
+	if !std.Enabled(LevelCritical) {
+		return
+	}
+	std.output(2, LevelCritical, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the CRITICAL level using the Standard Logger.
+func Critical(v ...interface{}) {
+	if !std.Enabled(LevelCritical) {
+		return
+	}
+	std.output(2, LevelCritical, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the ALERT level using the Standard Logger.
+func Alertf(format string, v ...interface{}) {
+	if !std.Enabled(LevelAlert) {
+		return
+	}
+	std.output(2, LevelAlert, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the ALERT level using the Standard Logger.
+func Alert(v ...interface{}) {
+	if !std.Enabled(LevelAlert) {
+		return
+	}
+	std.output(2, LevelAlert, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message at the EMERGENCY level using the Standard Logger.
+func Emergencyf(format string, v ...interface{}) {
+	if !std.Enabled(LevelEmergency) {
+		return
+	}
+	std.output(2, LevelEmergency, fmt.Sprintf(format, v...), argTags(v))
+}
+
+// Print a message at the EMERGENCY level using the Standard Logger.
+func Emergency(v ...interface{}) {
+	if !std.Enabled(LevelEmergency) {
+		return
+	}
+	std.output(2, LevelEmergency, fmt.Sprint(v...), argTags(v))
+}
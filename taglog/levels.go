@@ -1,11 +1,14 @@
 package taglog
 
 import (
+	"context"
+	"sort"
 	"strings"
 )
 
 type LevelSet struct {
 	levels       map[string]int // use a map to avoid searches
+	severities   map[string]int // explicit syslog severities
 	defaultLevel string
 }
 
@@ -66,6 +69,69 @@ func (ls *LevelSet) Contains(lvl string) bool {
 	return found
 }
 
+// Get the levels in order from lowest to highest.
+func (ls *LevelSet) Levels() []string {
+	levels := make([]string, 0, len(ls.levels))
+	for lvl := range ls.levels {
+		levels = append(levels, lvl)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return ls.levels[levels[i]] < ls.levels[levels[j]]
+	})
+	return levels
+}
+
+// Syslog severities. See RFC 5424.
+const (
+	SeverityEmergency = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// Set the syslog severity for a level. This should be done before the LevelSet
+// is used by a Logger.
+func (ls *LevelSet) SetSeverity(lvl string, severity int) {
+	if ls.severities == nil {
+		ls.severities = make(map[string]int)
+	}
+	ls.severities[strings.ToUpper(lvl)] = severity
+}
+
+// Get the syslog severity for a level. Levels without a severity set by
+// SetSeverity() are mapped by name, e.g. WARN and WARNING map to
+// SeverityWarning. Empty and unknown levels map to SeverityInfo.
+func (ls *LevelSet) Severity(lvl string) int {
+	lvl = strings.ToUpper(lvl)
+	if severity, found := ls.severities[lvl]; found {
+		return severity
+	}
+	switch lvl {
+	case LevelEmergency:
+		return SeverityEmergency
+	case LevelAlert:
+		return SeverityAlert
+	case LevelCritical, LevelFatal:
+		return SeverityCritical
+	case LevelError, LevelErr:
+		return SeverityError
+	case LevelWarning, LevelWarn:
+		return SeverityWarning
+	case LevelNotice:
+		return SeverityNotice
+	case LevelDebug, LevelTrace, LevelFine, LevelFiner, LevelFinest:
+		return SeverityDebug
+	}
+	return SeverityInfo
+}
+
+// The level-specific methods in leveled.go are generated from DefaultLevelSet.
+//go:generate go run genleveled.go
+
 var DefaultLevelSet = NewLevelSet([]string{
 	LevelDebug,
 	LevelInfo,
@@ -94,6 +160,29 @@ func (this *Logger) GetLevel() string {
 	return this.level
 }
 
+// Check whether lines at a level would be written. This can be used to skip
+// expensive work for lines which would be discarded. Lines without a level are
+// always written.
+func (this *Logger) Enabled(level string) bool {
+	this.mu.Lock()
+	enabled := this.enabled(level)
+	handler := this.handler
+	this.mu.Unlock()
+
+	if enabled && handler != nil {
+		return handler.Enabled(context.Background(), SlogLevel(level))
+	}
+	return enabled
+}
+
+// The caller must hold the lock.
+func (this *Logger) enabled(level string) bool {
+	if level == "" || this.levelset == nil || this.level == "" {
+		return true
+	}
+	return !this.levelset.Less(level, this.level)
+}
+
 func (this *Logger) SetLevelTag(tag string) {
 	this.levelTag = tag
 }
@@ -114,6 +203,11 @@ func GetLevel() string {
 	return std.GetLevel()
 }
 
+// Check whether the Standard Logger would write lines at a level.
+func Enabled(level string) bool {
+	return std.Enabled(level)
+}
+
 func SetLevelTag(tag string) {
 	std.SetLevelTag(tag)
 }
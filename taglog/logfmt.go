@@ -0,0 +1,160 @@
+package taglog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Encode a line in logfmt format. The timestamp is written as "time",
+// followed by the level, the caller, the message as "msg", and the remaining
+// tags in tag order. A tag with multiple values is written as a repeated key,
+// one pair per value. Global tags are written with the key "tags".
+func encodeLogfmt(b []byte, e *Entry) ([]byte, error) {
+	b = append(b, e.Params.Prefix...)
+	start := len(b)
+	sep := func() {
+		if len(b) > start {
+			b = append(b, ' ')
+		}
+	}
+
+	tags := flattenTags(e.AllTags())
+	if e.Timestamp != "" {
+		b = appendLogfmtPair(b, "time", e.Timestamp)
+	}
+
+	done := make(map[string]bool, 2)
+	for _, k := range []string{e.LevelTag, e.CallerTag} {
+		if k == "" || done[k] {
+			continue
+		}
+		done[k] = true
+		for _, v := range tags.GetAll(k) {
+			sep()
+			b = appendLogfmtPair(b, k, v)
+		}
+	}
+
+	sep()
+	b = appendLogfmtPair(b, "msg", e.Message)
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		if !done[k] {
+			keys = append(keys, k)
+		}
+	}
+	e.SortKeys(keys)
+	for _, k := range keys {
+		for _, v := range tags.GetAll(k) {
+			sep()
+			b = appendLogfmtPair(b, k, v)
+		}
+	}
+
+	return b, nil
+}
+
+// Append a key=value pair. Characters which are not allowed in logfmt keys are
+// replaced with underscores, and values are quoted when necessary.
+func appendLogfmtPair(b []byte, key string, value string) []byte {
+	if key == "" {
+		key = "tags"
+	}
+	for _, c := range key {
+		if c <= ' ' || c == '=' || c == '"' || c == utf8.RuneError || c == 0x7f {
+			c = '_'
+		}
+		b = utf8.AppendRune(b, c)
+	}
+	b = append(b, '=')
+	if logfmtNeedsQuote(value) {
+		return strconv.AppendQuote(b, value)
+	}
+	return append(b, value...)
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, c := range s {
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == utf8.RuneError || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// Decode a logfmt line into tags. The "time" key is returned as "timestamp".
+func decodeLogfmt(line string, params *Params) (Tags, []string, error) {
+	i := 0
+	if params.Prefix != "" {
+		if !strings.HasPrefix(line, params.Prefix) {
+			return nil, nil, &ParseError{Component: "prefix"}
+		}
+		i = len(params.Prefix)
+	}
+
+	tags := make(Tags)
+	var keys []string
+	for i < len(line) {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			return nil, nil, &ParseError{Offset: i, Component: "key"}
+		}
+
+		var value string
+		if i < len(line) && line[i] == '=' {
+			i++
+			if i < len(line) && line[i] == '"' {
+				end := i + 1
+				for end < len(line) && line[end] != '"' {
+					if line[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				if end >= len(line) {
+					return nil, nil, &ParseError{Offset: i, Component: "value", Err: fmt.Errorf("Unterminated quote")}
+				}
+				var err error
+				value, err = strconv.Unquote(line[i : end+1])
+				if err != nil {
+					return nil, nil, &ParseError{Offset: i, Component: "value", Err: err}
+				}
+				i = end + 1
+			} else {
+				start = i
+				for i < len(line) && line[i] != ' ' {
+					i++
+				}
+				value = line[start:i]
+			}
+		}
+
+		if key == "time" {
+			key = "timestamp"
+		}
+		if _, found := tags[key]; !found {
+			keys = append(keys, key)
+		}
+		tags.Add(key, value)
+	}
+
+	if _, found := tags["msg"]; !found {
+		return nil, nil, &ParseError{Offset: len(line), Component: "msg"}
+	}
+	return tags, keys, nil
+}
@@ -1,53 +1,94 @@
 package taglog
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"time"
 )
 
+// Writes each line to a list of loggers, which can be Loggers, other
+// MultiLoggers, or any other implementation of Interface. Loggers added with
+// Add() have a name and routing rules.
 type MultiLogger struct {
-	loggers []*Logger
+	loggers []Interface
+	names   []string // name of each logger, empty if unnamed
+	routes  []Route  // routing rules of each logger
+	states  []*childState
+	opts    FanOutOptions
 }
 
-func NewMultiLogger(loggers ...*Logger) *MultiLogger {
+func NewMultiLogger(loggers ...Interface) *MultiLogger {
 	mlog := new(MultiLogger)
 	mlog.loggers = loggers
+	mlog.names = make([]string, len(loggers))
+	mlog.routes = make([]Route, len(loggers))
+	mlog.states = newChildStates(len(loggers))
 	return mlog
 }
 
-func (mlog *MultiLogger) Copy() *MultiLogger {
-	newLoggers := make([]*Logger, len(mlog.loggers))
+// Create a MultiLogger with new loggers, keeping the names, routing rules, and
+// fan-out options. The circuit breaker state is shared unless fresh is set.
+func (mlog *MultiLogger) derive(fresh bool, fn func(Interface) Interface) *MultiLogger {
+	newLog := new(MultiLogger)
+	newLog.loggers = make([]Interface, len(mlog.loggers))
 	for i, logger := range mlog.loggers {
-		newLoggers[i] = logger.Copy()
+		newLog.loggers[i] = fn(logger)
+	}
+	newLog.names = append([]string(nil), mlog.names...)
+	newLog.routes = append([]Route(nil), mlog.routes...)
+	newLog.states = mlog.states
+	if fresh {
+		newLog.states = newChildStates(len(mlog.loggers))
 	}
-	return NewMultiLogger(newLoggers...)
+	newLog.opts = mlog.opts
+	return newLog
 }
 
-func (mlog *MultiLogger) Output(s string) error {
-	var anyErr error
+func (mlog *MultiLogger) Copy() *MultiLogger {
+	return mlog.derive(true, copyInterface)
+}
 
-	for _, logger := range mlog.loggers {
-		err := logger.Output(s)
-		if err != nil {
-			anyErr = err
-		}
-	}
+// Create a MultiLogger of child Loggers with one or more values for a key. See
+// Logger.With().
+func (mlog *MultiLogger) With(key string, value ...string) *MultiLogger {
+	tags := make(Tags)
+	tags.Add(key, value...)
+	return mlog.WithTags(tags)
+}
 
-	return anyErr
+// Create a MultiLogger of child Loggers with a set of tags. See
+// Logger.WithTags().
+func (mlog *MultiLogger) WithTags(tags Tags) *MultiLogger {
+	return mlog.derive(false, func(logger Interface) Interface {
+		return withTagsInterface(logger, tags)
+	})
+}
+
+func (mlog *MultiLogger) Output(s string) error {
+	return mlog.OutputDepth(2, s)
 }
 
 func (mlog *MultiLogger) Loutput(level string, s string) error {
-	var anyErr error
+	return mlog.LoutputDepth(2, level, s)
+}
 
-	for _, logger := range mlog.loggers {
-		err := logger.Loutput(level, s)
-		if err != nil {
-			anyErr = err
-		}
-	}
+func (mlog *MultiLogger) OutputDepth(calldepth int, s string) error {
+	return mlog.fanOut(calldepth+1, nil, "", true, s, nil)
+}
+
+func (mlog *MultiLogger) LoutputDepth(calldepth int, level string, s string) error {
+	return mlog.fanOut(calldepth+1, nil, level, false, s, nil)
+}
+
+func (mlog *MultiLogger) OutputDepthCtx(calldepth int, ctx context.Context, s string, tags Tags) error {
+	return mlog.fanOut(calldepth+1, ctx, "", true, s, tags)
+}
 
-	return anyErr
+func (mlog *MultiLogger) LoutputDepthCtx(calldepth int, ctx context.Context, level string, s string, tags Tags) error {
+	return mlog.fanOut(calldepth+1, ctx, level, false, s, tags)
 }
 
 func (mlog *MultiLogger) Params() Params {
@@ -122,6 +163,80 @@ func (mlog *MultiLogger) Format() int {
 	return mlog.loggers[0].Format()
 }
 
+func (mlog *MultiLogger) SetCallerTag(tag string) {
+	for _, logger := range mlog.loggers {
+		logger.SetCallerTag(tag)
+	}
+}
+
+func (mlog *MultiLogger) SetCallerSkip(skip int) {
+	for _, logger := range mlog.loggers {
+		logger.SetCallerSkip(skip)
+	}
+}
+
+func (mlog *MultiLogger) CallerSkip() int {
+	if len(mlog.loggers) == 0 {
+		return 0
+	}
+	return mlog.loggers[0].CallerSkip()
+}
+
+func (mlog *MultiLogger) DefineLevels(ls *LevelSet) {
+	for _, logger := range mlog.loggers {
+		logger.DefineLevels(ls)
+	}
+}
+
+func (mlog *MultiLogger) SetLevel(lvl string) {
+	for _, logger := range mlog.loggers {
+		logger.SetLevel(lvl)
+	}
+}
+
+func (mlog *MultiLogger) GetLevel() string {
+	if len(mlog.loggers) == 0 {
+		return ""
+	}
+	return mlog.loggers[0].GetLevel()
+}
+
+// Check whether any logger would write lines at a level, taking the minimum
+// levels of the routes into account. The tag predicates of the routes are not
+// checked. See Logger.Enabled().
+func (mlog *MultiLogger) Enabled(level string) bool {
+	now := time.Now()
+	for i, logger := range mlog.loggers {
+		if mlog.selectedLevel(i, now, level, false) && logger.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether any logger would write lines at its standard level.
+func (mlog *MultiLogger) enabledStandard() bool {
+	now := time.Now()
+	for i, logger := range mlog.loggers {
+		if mlog.selectedLevel(i, now, "", true) && logger.Enabled(standardLevelOf(logger)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (mlog *MultiLogger) SetLevelTag(tag string) {
+	for _, logger := range mlog.loggers {
+		logger.SetLevelTag(tag)
+	}
+}
+
+func (mlog *MultiLogger) SetStandardLevel(lvl string) {
+	for _, logger := range mlog.loggers {
+		logger.SetStandardLevel(lvl)
+	}
+}
+
 func (mlog *MultiLogger) AddTag(key string, value ...string) {
 	for _, logger := range mlog.loggers {
 		logger.AddTag(key, value...)
@@ -152,6 +267,19 @@ func (mlog *MultiLogger) SetTag(key string, value ...string) {
 	}
 }
 
+func (mlog *MultiLogger) SetTagValue(key string, value interface{}) {
+	for _, logger := range mlog.loggers {
+		logger.SetTagValue(key, value)
+	}
+}
+
+func (mlog *MultiLogger) GetTagValue(key string) interface{} {
+	if len(mlog.loggers) == 0 {
+		return nil
+	}
+	return mlog.loggers[0].GetTagValue(key)
+}
+
 func (mlog *MultiLogger) GetTag(key string) string {
 	if len(mlog.loggers) == 0 {
 		return ""
@@ -191,6 +319,12 @@ func (mlog *MultiLogger) ImportTags(tags map[string][]string) {
 	}
 }
 
+func (mlog *MultiLogger) SetTagOrder(order int, priority ...string) {
+	for _, logger := range mlog.loggers {
+		logger.SetTagOrder(order, priority...)
+	}
+}
+
 func (mlog *MultiLogger) SetOutput(w io.Writer) {
 	for _, logger := range mlog.loggers {
 		logger.SetOutput(w)
@@ -211,97 +345,192 @@ func (mlog *MultiLogger) ParseTags(tags []string) {
 }
 
 func (mlog *MultiLogger) Printf(format string, v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Printf(format, v...)
+	if !mlog.enabledStandard() {
+		return
 	}
+	mlog.OutputDepthCtx(2, nil, fmt.Sprintf(format, v...), argTags(v))
 }
 
 func (mlog *MultiLogger) Print(v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Print(v...)
+	if !mlog.enabledStandard() {
+		return
 	}
+	mlog.OutputDepthCtx(2, nil, fmt.Sprint(v...), argTags(v))
 }
 
 func (mlog *MultiLogger) Println(v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Println(v...)
+	if !mlog.enabledStandard() {
+		return
 	}
+	mlog.OutputDepthCtx(2, nil, fmt.Sprint(v...), argTags(v))
 }
 
 func (mlog *MultiLogger) Lprintf(level string, format string, v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Lprintf(level, format, v...)
+	if !mlog.Enabled(level) {
+		return
 	}
+	mlog.LoutputDepthCtx(2, nil, level, fmt.Sprintf(format, v...), argTags(v))
 }
 
 func (mlog *MultiLogger) Lprint(level string, v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Lprint(level, v...)
+	if !mlog.Enabled(level) {
+		return
 	}
+	mlog.LoutputDepthCtx(2, nil, level, fmt.Sprint(v...), argTags(v))
 }
 
 func (mlog *MultiLogger) Lprintln(level string, v ...interface{}) {
+	if !mlog.Enabled(level) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, level, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) Printw(msg string, kv ...interface{}) {
+	if !mlog.enabledStandard() {
+		return
+	}
+	mlog.OutputDepthCtx(2, nil, msg, kvTags(kv))
+}
+
+func (mlog *MultiLogger) Lprintw(level string, msg string, kv ...interface{}) {
+	if !mlog.Enabled(level) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, nil, level, msg, kvTags(kv))
+}
+
+func (mlog *MultiLogger) AddContextExtractor(fn ContextExtractor) {
 	for _, logger := range mlog.loggers {
-		logger.Lprintln(level, v...)
+		logger.AddContextExtractor(fn)
 	}
 }
 
-func (mlog *MultiLogger) Fatal(v ...interface{}) {
+func (mlog *MultiLogger) PrintfCtx(ctx context.Context, format string, v ...interface{}) {
+	if !mlog.enabledStandard() {
+		return
+	}
+	mlog.OutputDepthCtx(2, ctx, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) PrintCtx(ctx context.Context, v ...interface{}) {
+	if !mlog.enabledStandard() {
+		return
+	}
+	mlog.OutputDepthCtx(2, ctx, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) LprintfCtx(ctx context.Context, level string, format string, v ...interface{}) {
+	if !mlog.Enabled(level) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, ctx, level, fmt.Sprintf(format, v...), argTags(v))
+}
+
+func (mlog *MultiLogger) LprintCtx(ctx context.Context, level string, v ...interface{}) {
+	if !mlog.Enabled(level) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, ctx, level, fmt.Sprint(v...), argTags(v))
+}
+
+func (mlog *MultiLogger) LprintwCtx(ctx context.Context, level string, msg string, kv ...interface{}) {
+	if !mlog.Enabled(level) {
+		return
+	}
+	mlog.LoutputDepthCtx(2, ctx, level, msg, kvTags(kv))
+}
+
+func (mlog *MultiLogger) SetAsync(opts AsyncOptions) {
 	for _, logger := range mlog.loggers {
-		logger.Print(v...)
+		logger.SetAsync(opts)
 	}
-	os.Exit(1)
 }
 
-func (mlog *MultiLogger) Fatalf(format string, v ...interface{}) {
+func (mlog *MultiLogger) Flush() error {
+	var errs []error
+
+	for i, logger := range mlog.loggers {
+		err := logger.Flush()
+		if err != nil {
+			errs = append(errs, &ChildError{Index: i, Name: mlog.names[i], Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (mlog *MultiLogger) Close() error {
+	var errs []error
+
+	for i, logger := range mlog.loggers {
+		err := logger.Close()
+		if err != nil {
+			errs = append(errs, &ChildError{Index: i, Name: mlog.names[i], Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (mlog *MultiLogger) Dropped() uint64 {
+	var dropped uint64
 	for _, logger := range mlog.loggers {
-		logger.Printf(format, v...)
+		dropped += logger.Dropped()
 	}
+	return dropped
+}
+
+func (mlog *MultiLogger) Fatal(v ...interface{}) {
+	mlog.OutputDepthCtx(2, nil, fmt.Sprint(v...), argTags(v))
+	mlog.Flush()
+	os.Exit(1)
+}
+
+func (mlog *MultiLogger) Fatalf(format string, v ...interface{}) {
+	mlog.OutputDepthCtx(2, nil, fmt.Sprintf(format, v...), argTags(v))
+	mlog.Flush()
 	os.Exit(1)
 }
 
 func (mlog *MultiLogger) Fatalln(v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Println(v...)
-	}
+	mlog.OutputDepthCtx(2, nil, fmt.Sprint(v...), argTags(v))
+	mlog.Flush()
 	os.Exit(1)
 }
 
 func (mlog *MultiLogger) Lfatal(level string, v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Lprint(level, v...)
-	}
+	mlog.LoutputDepthCtx(2, nil, level, fmt.Sprint(v...), argTags(v))
+	mlog.Flush()
 	os.Exit(1)
 }
 
 func (mlog *MultiLogger) Lfatalf(level string, format string, v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Lprintf(level, format, v...)
-	}
+	mlog.LoutputDepthCtx(2, nil, level, fmt.Sprintf(format, v...), argTags(v))
+	mlog.Flush()
 	os.Exit(1)
 }
 
 func (mlog *MultiLogger) Lfatalln(level string, v ...interface{}) {
-	for _, logger := range mlog.loggers {
-		logger.Lprintln(level, v...)
-	}
+	mlog.LoutputDepthCtx(2, nil, level, fmt.Sprint(v...), argTags(v))
+	mlog.Flush()
 	os.Exit(1)
 }
 
 func (mlog *MultiLogger) Panic(v ...interface{}) {
 	s := fmt.Sprintln(v...)
-	mlog.Output(s)
+	mlog.OutputDepthCtx(2, nil, s, argTags(v))
 	panic(s)
 }
 
 func (mlog *MultiLogger) Panicf(format string, v ...interface{}) {
 	s := fmt.Sprintf(format, v...)
-	mlog.Output(s)
+	mlog.OutputDepthCtx(2, nil, s, argTags(v))
 	panic(s)
 }
 
 func (mlog *MultiLogger) Panicln(v ...interface{}) {
 	s := fmt.Sprintln(v...)
-	mlog.Output(s)
+	mlog.OutputDepthCtx(2, nil, s, argTags(v))
 	panic(s)
 }
@@ -0,0 +1,145 @@
+package taglog
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tag ordering policies, see SetTagOrder()
+const (
+	TagOrderAlphabetical = iota // tags are sorted by key
+	TagOrderInsertion           // tags are written in the order they were added
+)
+
+// The order in which the tags of a line are written. The zero value sorts tags
+// by key, except in plain format where the encoded tags are sorted as text.
+type tagOrder struct {
+	set      bool // SetTagOrder() was called
+	policy   int
+	priority []string // keys written first, in this order
+	inserted []string // keys in the order they were added, for TagOrderInsertion
+}
+
+// Rank a key. Keys are compared by class, then by index, then alphabetically.
+// Nested keys joined with "." are ranked by their longest ranked prefix.
+func (o *tagOrder) rank(key string) (class int, index int) {
+	for k := key; ; {
+		if i := indexOf(o.priority, k); i >= 0 {
+			return 0, i
+		}
+		if o.policy == TagOrderInsertion {
+			if i := indexOf(o.inserted, k); i >= 0 {
+				return 1, i
+			}
+		}
+		dot := strings.LastIndexByte(k, '.')
+		if dot < 0 {
+			return 2, 0
+		}
+		k = k[:dot]
+	}
+}
+
+// Check whether the tag with key a is written before the tag with key b.
+func (o *tagOrder) less(a string, b string) bool {
+	if a == b {
+		return false
+	}
+	ca, ia := o.rank(a)
+	cb, ib := o.rank(b)
+	if ca != cb {
+		return ca < cb
+	}
+	if ca < 2 && ia != ib {
+		return ia < ib
+	}
+	return a < b
+}
+
+// Sort keys in place.
+func (o *tagOrder) sort(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		return o.less(keys[i], keys[j])
+	})
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Update a list of keys in the order they were added: keys which were deleted
+// are removed, and new keys are appended in alphabetical order.
+func syncKeys(keys []string, tags Tags) []string {
+	n := 0
+	for _, k := range keys {
+		if _, found := tags[k]; found {
+			keys[n] = k
+			n++
+		}
+	}
+	keys = keys[:n]
+	if len(keys) == len(tags) {
+		return keys
+	}
+
+	start := len(keys)
+	for k := range tags {
+		if indexOf(keys[:start], k) < 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[start:])
+	return keys
+}
+
+// Get the keys of the tags of this Logger and its ancestors in the order they
+// were added. The result must not be modified. The caller must hold the lock.
+func (this *Logger) tagKeys() []string {
+	if this.parent == nil {
+		return this.keys
+	}
+	inherited := this.parent.tagKeys()
+	keys := make([]string, len(inherited), len(inherited)+len(this.keys))
+	copy(keys, inherited)
+	for _, k := range this.keys {
+		if indexOf(inherited, k) < 0 {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Get the tag order for a line. The caller must hold the lock.
+func (this *Logger) lineOrder() tagOrder {
+	o := this.order
+	if o.policy == TagOrderInsertion {
+		o.inserted = this.tagKeys()
+	}
+	return o
+}
+
+// Set the order in which tags are written. Tags are sorted by key with
+// TagOrderAlphabetical, or written in the order they were added to the Logger
+// and its ancestors with TagOrderInsertion. By default tags are sorted by key,
+// except in plain format where they are sorted by their encoded text, so that
+// global tags are mixed in with the others. Keys in priority are
+// written first, in the given order, and may name nested keys such as
+// "http.status". Other keys, such as per-line tags and tags from a context,
+// follow in alphabetical order. The order applies to the Logger and all
+// Loggers sharing its output.
+func (this *Logger) SetTagOrder(order int, priority ...string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.order = tagOrder{set: true, policy: order, priority: append([]string(nil), priority...)}
+	this.tagGen++
+}
+
+// Set the tag order for the Standard Logger.
+func SetTagOrder(order int, priority ...string) {
+	std.SetTagOrder(order, priority...)
+}
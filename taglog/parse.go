@@ -2,17 +2,58 @@ package taglog
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
 	"time"
 )
 
+// The maximum number of errors collected by a Parser in lenient mode.
+const maxParseErrors = 100
+
 // Used to parse logs created by taglog.
 type Parser struct {
-	params Params
-	tags   Tags
+	params   Params
+	tags     Tags
+	input    *entryReader // see SetInput
+	strict   bool
+	levelTag string
+	errors   []*ParseError // the first maxParseErrors errors
+	errCount int
+}
+
+// An error from parsing a log line.
+type ParseError struct {
+	Line      int    // line number in the input starting at 1, or 0 for ParseLine()
+	Offset    int    // byte offset in the line where parsing failed
+	Component string // part of the line which failed, e.g. "prefix", "timestamp", "caller", or "msg"
+	Err       error  // underlying error, or nil
+}
+
+func (e *ParseError) Error() string {
+	msg := "Log format mismatch: " + e.Component
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s at line %d, offset %d", msg, e.Line, e.Offset)
+	}
+	return fmt.Sprintf("%s at offset %d", msg, e.Offset)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Set the line number of an error from a Decoder. Errors other than
+// ParseErrors are wrapped with the "line" component.
+func lineError(err error, line int) *ParseError {
+	pe, ok := err.(*ParseError)
+	if !ok {
+		pe = &ParseError{Component: "line", Err: err}
+	}
+	pe.Line = line
+	return pe
 }
 
 // Create a new Parser instance.
@@ -20,6 +61,7 @@ func NewParser(params Params) *Parser {
 	p := new(Parser)
 	p.params = params
 	p.tags = make(Tags)
+	p.levelTag = "level"
 	return p
 }
 
@@ -28,9 +70,52 @@ func (this *Parser) Tags() map[string][]string {
 	return this.tags.Export()
 }
 
-// Clear all parsed tags.
+// Clear all parsed tags and errors.
 func (this *Parser) Reset() {
 	this.tags = make(Tags)
+	this.errors = nil
+	this.errCount = 0
+}
+
+// Set whether reading input stops at the first line which cannot be parsed.
+// By default the Parser is lenient: the error is collected, see Errors(), and
+// the line is skipped by ParseInput() or added to the message of the previous
+// line by Next() and the conversions to JSON. In strict mode the ParseError is
+// returned instead.
+func (this *Parser) SetStrict(strict bool) {
+	this.strict = strict
+}
+
+// Set the tag the level of a Record is read from, as set with
+// Logger.SetLevelTag() when the log was written. It defaults to "level".
+func (this *Parser) SetLevelTag(tag string) {
+	this.levelTag = tag
+}
+
+// Get the errors collected in lenient mode since the last Reset(). Only the
+// first 100 errors are kept, see ErrorCount().
+func (this *Parser) Errors() []*ParseError {
+	return this.errors
+}
+
+// Get the number of errors in lenient mode since the last Reset(), including
+// those which were not kept by Errors().
+func (this *Parser) ErrorCount() int {
+	return this.errCount
+}
+
+// Handle a line which cannot be parsed. The error is returned in strict mode,
+// and collected otherwise.
+func (this *Parser) lineFailed(err error, line int) error {
+	pe := lineError(err, line)
+	if this.strict {
+		return pe
+	}
+	this.errCount++
+	if len(this.errors) < maxParseErrors {
+		this.errors = append(this.errors, pe)
+	}
+	return nil
 }
 
 // Merge tags from a map of string slices.
@@ -38,196 +123,235 @@ func (this *Parser) MergeTags(newTags map[string][]string) {
 	this.tags.Import(newTags)
 }
 
-func (this *Parser) parseLinePlain(line string, timestampFormat string) (Tags, error) {
+// Decode a line in plain format. Tags and the message are unescaped, see
+// escape.go.
+func decodePlain(line string, params *Params) (Tags, []string, error) {
 	tags := make(Tags)
+	var keys []string
+	add := func(key string, value ...string) {
+		if _, found := tags[key]; !found {
+			keys = append(keys, key)
+		}
+		tags.Add(key, value...)
+	}
+	n := len(line)
+	mismatch := func(component string, err error) error {
+		return &ParseError{Offset: n - len(line), Component: component, Err: err}
+	}
 
-	if this.params.Prefix != "" {
-		if !strings.HasPrefix(line, this.params.Prefix) {
-			return nil, fmt.Errorf("Log format mismatch: prefix")
+	if params.Prefix != "" {
+		if !strings.HasPrefix(line, params.Prefix) {
+			return nil, nil, mismatch("prefix", nil)
 		}
-		line = strings.TrimPrefix(line, this.params.Prefix)
+		line = strings.TrimPrefix(line, params.Prefix)
+	}
+	if line == "" {
+		return nil, nil, mismatch("line", nil)
 	}
 
-	tsFormat := calcTsFormat(&this.params)
+	tsFormat := calcTsFormat(params)
 	if tsFormat != "" {
 		fmtTokens := len(strings.Split(tsFormat, " "))
 		lineSplit := strings.Split(line, " ")
 		if len(lineSplit) < fmtTokens {
-			return nil, fmt.Errorf("Log format mismatch: timestamp")
+			return nil, nil, mismatch("timestamp", nil)
 		}
 		lineSplit = lineSplit[:fmtTokens]
 		tsStr := strings.Join(lineSplit, " ")
 
-		ts, err := time.Parse(tsFormat, tsStr)
+		_, err := time.Parse(tsFormat, tsStr)
 		if err != nil {
-			return nil, fmt.Errorf("Log format mismatch: timestamp")
-		}
-		if timestampFormat == "" {
-			tags.Add("timestamp", tsStr)
-		} else {
-			tags.Add("timestamp", ts.Format(timestampFormat))
+			return nil, nil, mismatch("timestamp", err)
 		}
+		add("timestamp", tsStr)
 
 		line = strings.TrimPrefix(line, tsStr)
-		line = strings.TrimLeft(line, " ")
-	}
-
-	tokenStart := -1
-	msgStart := 0
-	var tagTokens []string
-	for i, c := range line {
-		if tokenStart < 0 {
-			if c == ']' {
-				break
-			} else if c == '[' {
-				tokenStart = i
-			}
-		} else {
-			if c == ']' {
-				tagTokens = append(tagTokens, line[tokenStart+1:i])
-				tokenStart = -1
-				msgStart = i + 2
+		line = strings.TrimPrefix(line, " ")
+	}
+
+	if params.Flag&(Llongfile|Lshortfile) != 0 {
+		end := strings.Index(line, ": ")
+		if end < 0 {
+			if !strings.HasSuffix(line, ":") {
+				return nil, nil, mismatch("caller", nil)
 			}
+			end = len(line) - 1
 		}
+		add("caller", line[:end])
+		line = strings.TrimPrefix(line[end+1:], " ")
 	}
-	if msgStart >= len(line) {
-		return nil, fmt.Errorf("Empty line")
-	}
-	tags.Add("msg", line[msgStart:])
 
-	for _, t := range tagTokens {
-		pairs := strings.Split(t, "=")
-		if len(pairs) == 1 {
-			tags.Add("tags", pairs[0])
+	// tags are at the start of the line, each followed by a space
+	for strings.HasPrefix(line, "[") {
+		end := indexPlainTag(line[1:], ']')
+		if end < 0 {
+			break
+		}
+		token := line[1 : end+1]
+		line = strings.TrimPrefix(line[end+2:], " ")
+
+		eq := indexPlainTag(token, '=')
+		if eq < 0 {
+			add("tags", unescapePlain(token, plainTagEscapes))
 		} else {
-			key := pairs[0]
-			values := strings.Split(pairs[1], ",")
-			tags.Add(key, values...)
+			add(unescapePlain(token[:eq], plainTagEscapes), splitPlainValues(token[eq+1:])...)
 		}
 	}
 
-	return tags, nil
+	add("msg", unescapePlain(line, plainMsgEscapes))
+
+	return tags, keys, nil
 }
 
-func (this *Parser) parseLineJSON(line string) (Tags, error) {
-	tags := make(Tags)
+// Decode a line. timestampFormat specifies the timestamp format of the result.
+// An empty string retains the timestamp format from the input. The keys are
+// returned in the order they appear in the line, or nil if the Decoder does not
+// report the order.
+func (this *Parser) decodeLine(dec Decoder, line string, timestampFormat string) (Tags, []string, error) {
+	if dec == nil {
+		return nil, nil, fmt.Errorf("Invalid format")
+	}
 
-	err := json.Unmarshal([]byte(line), &tags)
+	var tags Tags
+	var keys []string
+	var err error
+	if odec, ok := dec.(orderedDecoderFunc); ok {
+		tags, keys, err = odec(line, &this.params)
+	} else {
+		tags, err = dec.Decode(line, &this.params)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return tags, nil
-}
-
-func (this *Parser) mergeLinePlain(line string) error {
-	tags, err := this.parseLinePlain(line, "")
-	if err != nil {
-		return err
+	tsStr := tags.Get("timestamp")
+	tsFormat := calcTsFormat(&this.params)
+	if tsStr != "" && tsFormat != "" && timestampFormat != "" {
+		ts, err := time.Parse(tsFormat, tsStr)
+		if err != nil {
+			return nil, nil, &ParseError{Offset: max(strings.Index(line, tsStr), 0), Component: "timestamp", Err: err}
+		}
+		tags.Set("timestamp", ts.Format(timestampFormat))
 	}
-	tags.Del("msg")
-	tags.Del("timestamp")
 
-	this.MergeTags(tags.Export())
-	return nil
+	return tags, keys, nil
 }
 
-func (this *Parser) mergeLineJSON(line string) error {
-	tags := make(Tags)
-
-	err := json.Unmarshal([]byte(line), &tags)
+// Parse a single log line. Tags which only describe the line, such as the
+// message and the tags written by LogError(), are not kept.
+func (this *Parser) ParseLine(line string) error {
+	tags, _, err := this.decodeLine(FormatDecoder(this.params.Format), line, "")
 	if err != nil {
 		return err
 	}
 	tags.Del("msg")
 	tags.Del("timestamp")
+	tags.Del("caller")
+	tags.Del(ErrorTag)
+	tags.Del(ErrorTypeTag)
+	tags.Del(ErrorChainTag)
+	tags.Del(StackTag)
 
 	this.MergeTags(tags.Export())
 	return nil
 }
 
-// Parse a single log line.
-func (this *Parser) ParseLine(line string) error {
-	switch this.params.Format {
-	case FormatPlain:
-		return this.mergeLinePlain(line)
-	case FormatJSON:
-		return this.mergeLineJSON(line)
-	}
-	return fmt.Errorf("Invalid format")
-}
-
-func (this *Parser) parseInputPlain(input io.Reader) error {
-	scanner := bufio.NewScanner(input)
-	for scanner.Scan() {
-		this.ParseLine(scanner.Text())
+// Parse all lines from an io.Reader. Empty lines and the stack traces written
+// after lines in plain format are skipped. Lines which cannot be parsed are
+// handled as set with SetStrict().
+func (this *Parser) ParseInput(input io.Reader) error {
+	if FormatDecoder(this.params.Format) == nil {
+		return fmt.Errorf("Invalid format")
 	}
-	return scanner.Err()
-}
 
-func (this *Parser) parseInputJSON(input io.Reader) error {
 	scanner := bufio.NewScanner(input)
+	line := 0
 	for scanner.Scan() {
+		line++
 		s := scanner.Text()
-		err := this.ParseLine(s)
-		if err != nil {
-			return err
+		if s == "" || strings.HasPrefix(s, "\t") {
+			continue
+		}
+		if err := this.ParseLine(s); err != nil {
+			if err = this.lineFailed(err, line); err != nil {
+				return err
+			}
 		}
 	}
 	return scanner.Err()
 }
 
-// Parse all lines from an io.Reader
-func (this *Parser) ParseInput(input io.Reader) error {
-	switch this.params.Format {
-	case FormatPlain:
-		return this.parseInputPlain(input)
-	case FormatJSON:
-		return this.parseInputJSON(input)
-	}
-	return fmt.Errorf("Invalid format")
-}
-
 // Convert Plain format input to JSON format output. timestampFormat specifies
 // the output timestamp format. An empty string retains the timestamp format
 // from the input.
 func (this *Parser) PlainToJSON(input io.Reader, output io.Writer, timestampFormat string) error {
-	var s string
-	var lineTags Tags
+	return this.convertToJSON(FormatDecoder(FormatPlain), input, output, timestampFormat)
+}
 
-	scanner := bufio.NewScanner(input)
+// Convert logfmt format input to JSON format output. timestampFormat specifies
+// the output timestamp format. An empty string retains the timestamp format
+// from the input.
+func (this *Parser) LogfmtToJSON(input io.Reader, output io.Writer, timestampFormat string) error {
+	return this.convertToJSON(FormatDecoder(FormatLogfmt), input, output, timestampFormat)
+}
 
-	// get line 1
-	// skip any non-starting lines at the beginning
-	for scanner.Scan() {
-		s = scanner.Text()
-		tags, err := this.parseLinePlain(s, timestampFormat)
-		if err == nil {
-			lineTags = tags
-			break
-		}
-	}
-	if s == "" {
-		return scanner.Err()
+// Convert input in the format of the Parser to JSON format output.
+// timestampFormat specifies the output timestamp format. An empty string
+// retains the timestamp format from the input.
+func (this *Parser) ToJSON(input io.Reader, output io.Writer, timestampFormat string) error {
+	dec := FormatDecoder(this.params.Format)
+	if dec == nil {
+		return fmt.Errorf("Invalid format")
 	}
+	return this.convertToJSON(dec, input, output, timestampFormat)
+}
 
-	// get line 2
-	for scanner.Scan() {
-		s = scanner.Text()
-		tags, err := this.parseLinePlain(s, timestampFormat)
-		if err == nil {
-			b, err := json.Marshal(&lineTags)
-			if err == nil {
-				fmt.Fprintln(output, string(b))
+// Convert input to JSON format output using a Decoder. Lines which cannot be
+// decoded or encoded are handled as set with SetStrict(), and lines starting
+// with a tab are the stack trace of the previous line. Fields are written in
+// the order they appear in the input when the Decoder reports it. Errors
+// writing the output are returned.
+func (this *Parser) convertToJSON(dec Decoder, input io.Reader, output io.Writer, timestampFormat string) error {
+	r := this.newEntryReader(dec, input, timestampFormat)
+	for {
+		e, err := r.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b, err := appendJSONTags(nil, e.tags, e.keys)
+		if err != nil {
+			// lines which cannot be encoded are skipped in lenient mode
+			if err = this.lineFailed(err, e.line); err != nil {
+				return err
 			}
-			lineTags = tags
-		} else {
-			lineTags.Set("msg", lineTags.Get("msg")+"\n"+s)
+			continue
+		}
+		_, err = fmt.Fprintln(output, string(b))
+		if err != nil {
+			return err
 		}
 	}
-	b, err := json.Marshal(&lineTags)
-	if err == nil {
-		fmt.Fprintln(output, string(b))
+}
+
+// Append tags as a JSON object with the keys in the given order. Keys which
+// are not listed follow in alphabetical order.
+func appendJSONTags(b []byte, tags Tags, keys []string) ([]byte, error) {
+	frags := getFragments()
+	defer putFragments(frags)
+	err := frags.addJSONTags(tags, &tagOrder{policy: TagOrderInsertion, inserted: keys})
+	if err != nil {
+		return b, err
 	}
-	return nil
+
+	b = append(b, '{')
+	for i := range frags.list {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, frags.bytes(i)...)
+	}
+	return append(b, '}'), nil
 }
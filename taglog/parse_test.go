@@ -0,0 +1,49 @@
+package taglog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(b []byte) (int, error) {
+	return 0, w.err
+}
+
+// Errors writing the output of a conversion are returned.
+func TestToJSONWriteError(t *testing.T) {
+	want := errors.New("write failed")
+	p := NewParser(Params{Format: FormatPlain})
+	err := p.ToJSON(strings.NewReader("[k=v] message\n"), failingWriter{want}, "")
+	if !errors.Is(err, want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+// The level of a Record is read from the level tag the log was written with.
+func TestRecordLevelTag(t *testing.T) {
+	for _, format := range []int{FormatPlain, FormatJSON, FormatLogfmt} {
+		var buf bytes.Buffer
+		logger := New(&buf, "", 0)
+		logger.SetFormat(format)
+		logger.SetLevel(LevelDebug)
+		logger.SetLevelTag("severity")
+		logger.Lprint("WARNING", "message")
+
+		p := NewParser(Params{Format: format})
+		p.SetLevelTag("severity")
+		p.SetInput(&buf)
+		rec, err := p.Next()
+		if err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		if rec.Level != "WARNING" || len(rec.Tags) != 0 {
+			t.Errorf("format %d: got level %q and tags %v from %q", format, rec.Level, rec.Tags, rec.Raw)
+		}
+	}
+}
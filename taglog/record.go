@@ -0,0 +1,186 @@
+package taglog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"time"
+)
+
+// A log line read by the Parser.
+type Record struct {
+	Time    time.Time // zero if the line has no timestamp or it cannot be parsed
+	Level   string    // from the level tag, see Parser.SetLevelTag(), empty if the line has no level
+	Message string
+	Tags    Tags   // the remaining tags, including "timestamp" if it cannot be parsed
+	Raw     string // the input lines of the record
+	Line    int    // line number of the first input line, starting at 1
+}
+
+// A decoded line along with the lines which continue it.
+type parsedEntry struct {
+	tags Tags
+	keys []string // in the order they appear in the input, see Parser.decodeLine
+	raw  string
+	line int
+}
+
+// Reads entries from input. Lines which cannot be decoded are appended to the
+// message of the previous entry in lenient mode, as are empty lines, and lines
+// starting with a tab are the stack trace of the previous entry. Lines before
+// the first entry are skipped.
+type entryReader struct {
+	parser          *Parser
+	dec             Decoder
+	timestampFormat string
+	scanner         *bufio.Scanner
+	line            int
+	pending         *parsedEntry // decoded, but may be continued by the next line
+	err             error        // returned after pending in strict mode
+}
+
+func (this *Parser) newEntryReader(dec Decoder, input io.Reader, timestampFormat string) *entryReader {
+	return &entryReader{
+		parser:          this,
+		dec:             dec,
+		timestampFormat: timestampFormat,
+		scanner:         bufio.NewScanner(input),
+	}
+}
+
+// Get the next entry, or io.EOF at the end of the input.
+func (r *entryReader) next() (*parsedEntry, error) {
+	if err := r.err; err != nil {
+		r.err = nil
+		return nil, err
+	}
+	for r.scanner.Scan() {
+		r.line++
+		s := r.scanner.Text()
+		e := r.pending
+
+		if e != nil && strings.HasPrefix(s, "\t") {
+			// stack trace written after the line in plain format
+			stack := e.tags.Get(StackTag)
+			if stack == "" {
+				e.keys = append(e.keys, StackTag)
+			} else {
+				stack += "\n"
+			}
+			e.tags.Set(StackTag, stack+s[1:])
+			e.raw += "\n" + s
+			continue
+		}
+
+		tags, keys, err := r.parser.decodeLine(r.dec, s, r.timestampFormat)
+		if err != nil {
+			if s != "" {
+				if err = r.parser.lineFailed(err, r.line); err != nil {
+					r.pending = nil
+					if e != nil {
+						r.err = err
+						return e, nil
+					}
+					return nil, err
+				}
+			}
+			if e != nil {
+				e.tags.Set("msg", e.tags.Get("msg")+"\n"+s)
+				e.raw += "\n" + s
+			}
+			continue
+		}
+
+		r.pending = &parsedEntry{tags: tags, keys: keys, raw: s, line: r.line}
+		if e != nil {
+			return e, nil
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	e := r.pending
+	r.pending = nil
+	if e == nil {
+		return nil, io.EOF
+	}
+	return e, nil
+}
+
+// Start reading records from an io.Reader with Next().
+func (this *Parser) SetInput(input io.Reader) {
+	this.input = this.newEntryReader(FormatDecoder(this.params.Format), input, "")
+}
+
+// Read the next record from the input set with SetInput(). Messages which
+// span several lines are combined into one record, the same way as ToJSON().
+// In strict mode a ParseError is returned for a line which cannot be decoded.
+// io.EOF is returned at the end of the input.
+func (this *Parser) Next() (Record, error) {
+	if this.input == nil {
+		return Record{}, fmt.Errorf("No input")
+	}
+	if this.input.dec == nil {
+		return Record{}, fmt.Errorf("Invalid format")
+	}
+
+	e, err := this.input.next()
+	if err != nil {
+		return Record{}, err
+	}
+	return this.record(e), nil
+}
+
+// Iterate over the records of an io.Reader. See Next(). Iteration stops after
+// an error is yielded.
+func (this *Parser) Records(input io.Reader) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		this.SetInput(input)
+		for {
+			rec, err := this.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(rec, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Convert a decoded entry to a Record. The timestamp is parsed with the
+// timestamp format of the Parser, or as RFC 3339.
+func (this *Parser) record(e *parsedEntry) Record {
+	tags := e.tags
+	rec := Record{
+		Message: tags.Get("msg"),
+		Raw:     e.raw,
+		Line:    e.line,
+	}
+	tags.Del("msg")
+	if this.levelTag != "" {
+		rec.Level = tags.Get(this.levelTag)
+		tags.Del(this.levelTag)
+	}
+
+	if tsStr := tags.Get("timestamp"); tsStr != "" {
+		loc := time.Local
+		if this.params.Flag&LUTC != 0 {
+			loc = time.UTC
+		}
+		ts, err := time.ParseInLocation(calcTsFormat(&this.params), tsStr, loc)
+		if err != nil {
+			ts, err = time.Parse(time.RFC3339Nano, tsStr)
+		}
+		if err == nil {
+			rec.Time = ts
+			tags.Del("timestamp")
+		}
+	}
+
+	rec.Tags = tags
+	return rec
+}
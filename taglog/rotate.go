@@ -0,0 +1,363 @@
+package taglog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Options for a RotatingFile.
+type RotateOptions struct {
+	MaxSize    int64         // rotate before the file would exceed this many bytes, 0 disables
+	Interval   time.Duration // rotate when the file has been open this long, 0 disables
+	MaxBackups int           // number of rotated files to keep, 0 keeps all
+	Compress   bool          // gzip rotated files in the background
+	Mode       os.FileMode   // permissions for new files, defaults to 0644
+}
+
+// Format of the timestamp added to the names of rotated files. It sorts in
+// chronological order.
+const rotateTimestampFormat = "2006-01-02T15-04-05.000"
+
+// How long to wait before trying again when rotating before a Write fails.
+const rotateRetryDelay = time.Minute
+
+// An io.WriteCloser which writes to a file and rotates it based on size and
+// age. Rotated files are renamed by adding a timestamp before the extension,
+// e.g. "app.log" is rotated to "app-2014-07-24T22-08-56.840.log". If rotating
+// before a Write fails, the line is still written to the current file, the
+// rotation is retried after a minute, and the error is returned by Close. If
+// the file cannot be opened again after rotating it, the next Write retries. It
+// is safe for concurrent use, including by several Loggers.
+type RotatingFile struct {
+	mu     sync.Mutex
+	path   string
+	opts   RotateOptions
+	file   *os.File // nil if reopening it failed, see reopen()
+	closed bool
+	size   int64
+	opened time.Time
+	retry  time.Time      // no rotation before this time after a failed one
+	wg     sync.WaitGroup // background compression
+	errMu  sync.Mutex
+	bgErr  error // first error from background compression or rotation
+}
+
+// Open a file for writing with rotation. The file is created if it does not
+// exist, and appended to if it does.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if opts.Mode == 0 {
+		opts.Mode = 0644
+	}
+	rf := new(RotatingFile)
+	rf.path = path
+	rf.opts = opts
+	err := rf.open()
+	if err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// The caller must hold the lock.
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, rf.opts.Mode)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rf.file = file
+	rf.size = info.Size()
+	rf.opened = time.Now()
+	return nil
+}
+
+// Open the file again if a previous rotation or reopen failed to. The caller
+// must hold the lock.
+func (rf *RotatingFile) reopen() error {
+	if rf.closed {
+		return os.ErrClosed
+	}
+	if rf.file == nil {
+		return rf.open()
+	}
+	return nil
+}
+
+// Write to the file, rotating it first if necessary. See io.Writer.
+func (rf *RotatingFile) Write(b []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	err := rf.reopen()
+	if err != nil {
+		return 0, err
+	}
+
+	if rf.needsRotate(int64(len(b))) {
+		err := rf.rotate()
+		if err != nil {
+			rf.setBgErr(err)
+			rf.retry = time.Now().Add(rotateRetryDelay)
+			if rf.file == nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err := rf.file.Write(b)
+	rf.size += int64(n)
+	return n, err
+}
+
+// The caller must hold the lock.
+func (rf *RotatingFile) needsRotate(n int64) bool {
+	if rf.size == 0 || time.Now().Before(rf.retry) {
+		return false
+	}
+	if rf.opts.MaxSize > 0 && rf.size+n > rf.opts.MaxSize {
+		return true
+	}
+	if rf.opts.Interval > 0 && time.Since(rf.opened) >= rf.opts.Interval {
+		return true
+	}
+	return false
+}
+
+// Rotate the file now.
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	err := rf.reopen()
+	if err != nil {
+		return err
+	}
+	return rf.rotate()
+}
+
+// If the file cannot be renamed, writing continues to the current file. The
+// caller must hold the lock.
+func (rf *RotatingFile) rotate() error {
+	err := rf.file.Close()
+	rf.file = nil
+	if err != nil {
+		return err
+	}
+
+	backup := rf.backupName(time.Now())
+	err = os.Rename(rf.path, backup)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		if oerr := rf.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+
+	err = rf.open()
+	if err != nil {
+		return err
+	}
+
+	if rf.opts.Compress {
+		rf.wg.Add(1)
+		go func() {
+			defer rf.wg.Done()
+			rf.setBgErr(compressFile(backup))
+			rf.setBgErr(rf.prune())
+		}()
+		return nil
+	}
+	return rf.prune()
+}
+
+// Generate an unused name for a rotated file.
+func (rf *RotatingFile) backupName(now time.Time) string {
+	ext := filepath.Ext(rf.path)
+	base := strings.TrimSuffix(rf.path, ext)
+	name := base + "-" + now.Format(rotateTimestampFormat)
+	backup := name + ext
+	for i := 1; fileExists(backup) || fileExists(backup+".gz"); i++ {
+		backup = name + "." + strconv.Itoa(i) + ext
+	}
+	return backup
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// Remove the oldest rotated files beyond MaxBackups.
+func (rf *RotatingFile) prune() error {
+	if rf.opts.MaxBackups <= 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(rf.path)
+	base := strings.TrimSuffix(rf.path, ext)
+	matches, err := filepath.Glob(base + "-*")
+	if err != nil {
+		return err
+	}
+
+	// backups are identified by their timestamp, so a compressed file and its
+	// original being compressed count once
+	byStamp := make(map[string][]string)
+	for _, m := range matches {
+		rest := strings.TrimPrefix(m, base+"-")
+		rest = strings.TrimSuffix(rest, ".gz")
+		rest = strings.TrimSuffix(rest, ext)
+		if _, err := time.Parse(rotateTimestampFormat, rest[:min(len(rest), len(rotateTimestampFormat))]); err != nil {
+			continue
+		}
+		byStamp[rest] = append(byStamp[rest], m)
+	}
+
+	stamps := make([]string, 0, len(byStamp))
+	for stamp := range byStamp {
+		stamps = append(stamps, stamp)
+	}
+	sort.Strings(stamps)
+
+	var anyErr error
+	for len(stamps) > rf.opts.MaxBackups {
+		for _, m := range byStamp[stamps[0]] {
+			err := os.Remove(m)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				anyErr = err
+			}
+		}
+		stamps = stamps[1:]
+	}
+	return anyErr
+}
+
+// Compress a file with gzip and remove the original.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	in.Close()
+	return os.Remove(path)
+}
+
+func (rf *RotatingFile) setBgErr(err error) {
+	if err == nil {
+		return
+	}
+	rf.errMu.Lock()
+	defer rf.errMu.Unlock()
+	if rf.bgErr == nil {
+		rf.bgErr = err
+	}
+}
+
+// Close and reopen the file. This is used after the file has been moved by an
+// external tool such as logrotate.
+func (rf *RotatingFile) Reopen() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return os.ErrClosed
+	}
+	if rf.file != nil {
+		err := rf.file.Close()
+		rf.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	return rf.open()
+}
+
+// Reopen the file whenever one of the signals is received. If no signals are
+// given, SIGHUP is used. The returned function stops handling the signals.
+func (rf *RotatingFile) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				rf.setBgErr(rf.Reopen())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Close the file and wait for background compression to finish. The first
+// error from background work or from rotating before a Write is returned if
+// closing succeeds.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	var err error
+	rf.closed = true
+	if rf.file != nil {
+		err = rf.file.Close()
+		rf.file = nil
+	}
+	rf.mu.Unlock()
+
+	rf.wg.Wait()
+	if err == nil {
+		rf.errMu.Lock()
+		err = rf.bgErr
+		rf.bgErr = nil
+		rf.errMu.Unlock()
+	}
+	return err
+}
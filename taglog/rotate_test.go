@@ -0,0 +1,41 @@
+package taglog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Lines are still written when rotating fails, and the rotation is not retried
+// on every Write.
+func TestRotateRenameFails(t *testing.T) {
+	// the name of the rotated file is too long for the file system
+	path := filepath.Join(t.TempDir(), strings.Repeat("a", 240)+".log")
+	rf, err := OpenRotatingFile(path, RotateOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{"line 1\n", "line 2\n", "line 3\n"}
+	for _, line := range lines {
+		n, err := rf.Write([]byte(line))
+		if n != len(line) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", line, n, err)
+		}
+	}
+	if rf.retry.IsZero() {
+		t.Error("the rotation will be retried on the next Write")
+	}
+
+	if err := rf.Close(); err == nil {
+		t.Error("Close did not return the rotation error")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), strings.Join(lines, ""); got != want {
+		t.Errorf("file contains %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package taglog
+
+import (
+	"context"
+)
+
+// Rules which select the lines written to a logger in a MultiLogger. The zero
+// value selects all lines.
+type Route struct {
+	Level    string               // minimum level, lines without a level are not filtered
+	LevelSet *LevelSet            // used to compare levels, defaults to DefaultLevelSet
+	Include  func(tags Tags) bool // if set, only lines for which it returns true are written
+	Exclude  func(tags Tags) bool // if set, lines for which it returns true are not written
+}
+
+// Check whether a line is selected by the route. The tag predicates are given
+// the tags of the logger, the tags carried by the context, and the per-line
+// tags. Tags from context extractors are not included.
+func (r *Route) allows(logger Interface, ctx context.Context, level string, tags Tags) bool {
+	if !r.allowsLevel(level) {
+		return false
+	}
+
+	if r.Include == nil && r.Exclude == nil {
+		return true
+	}
+
+	lineTags := make(Tags)
+	lineTags.Import(logger.ExportTags())
+	if ctxTags := FromContext(ctx); ctxTags != nil {
+		layerTags(lineTags, ctxTags)
+	}
+	if tags != nil {
+		layerTags(lineTags, tags)
+	}
+
+	if r.Include != nil && !r.Include(lineTags) {
+		return false
+	}
+	if r.Exclude != nil && r.Exclude(lineTags) {
+		return false
+	}
+	return true
+}
+
+// Check whether a level is at least the minimum level of the route.
+func (r *Route) allowsLevel(level string) bool {
+	if level == "" || r.Level == "" {
+		return true
+	}
+	ls := r.LevelSet
+	if ls == nil {
+		ls = DefaultLevelSet
+	}
+	return !ls.Less(level, r.Level)
+}
+
+// Add a named logger with routing rules. The name can be used with Child() and
+// SetRoute(), and should be unique. Loggers should be added before the
+// MultiLogger is used.
+func (mlog *MultiLogger) Add(name string, logger Interface, route Route) {
+	mlog.loggers = append(mlog.loggers, logger)
+	mlog.names = append(mlog.names, name)
+	mlog.routes = append(mlog.routes, route)
+	mlog.states = append(mlog.states, new(childState))
+}
+
+// Get a logger by name, or nil if there is none. Setters called on the result
+// only affect that logger, e.g. Child("stderr").SetFormat(FormatPlain).
+func (mlog *MultiLogger) Child(name string) Interface {
+	for i, n := range mlog.names {
+		if n == name {
+			return mlog.loggers[i]
+		}
+	}
+	return nil
+}
+
+// Replace the routing rules of a named logger. It returns false if there is
+// no logger with the name. Like Add(), it should not be called while the
+// MultiLogger is in use.
+func (mlog *MultiLogger) SetRoute(name string, route Route) bool {
+	for i, n := range mlog.names {
+		if n == name {
+			mlog.routes[i] = route
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package taglog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// Routes which select lines by their tags receive lines from the methods which
+// check Enabled() first.
+func TestRouteIncludeLineTags(t *testing.T) {
+	var buf bytes.Buffer
+	mlog := NewMultiLogger()
+	mlog.Add("audit", New(&buf, "", 0), Route{
+		Include: func(tags Tags) bool { return tags.Get("audit") != "" },
+	})
+
+	ctx := NewContext(context.Background(), Tags{"audit": []string{"ctx"}})
+	mlog.Lprintw("INFO", "kv", "audit", "kv")
+	mlog.Lprintw("INFO", "dropped", "other", "kv")
+	mlog.LprintfCtx(ctx, "INFO", "ctx")
+	mlog.Printw("printw", "audit", "printw")
+
+	want := "[audit=kv] [level=INFO] kv\n[audit=ctx] [level=INFO] ctx\n[audit=printw] printw\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
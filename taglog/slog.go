@@ -0,0 +1,239 @@
+package taglog
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A slog.Handler which writes log records through a Logger. slog levels are
+// mapped onto the LevelSet of the Logger, see SlogLevelName(). Attributes are
+// added as tags. Attributes in groups are flattened by joining the group names
+// and the attribute key with a "." (e.g. "http.status"). Attributes with an
+// empty key are added as global tags.
+type SlogHandler struct {
+	logger *Logger
+	prefix string // group names joined and terminated with "."
+}
+
+// Create a new slog.Handler which writes through a Logger.
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	h := new(SlogHandler)
+	h.logger = logger
+	return h
+}
+
+// Get the Logger used by the handler.
+func (h *SlogHandler) Logger() *Logger {
+	return h.logger
+}
+
+// See slog.Handler.Enabled
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	h.logger.mu.Lock()
+	defer h.logger.mu.Unlock()
+	if h.logger.levelset == nil || h.logger.level == "" {
+		return true
+	}
+	return !h.logger.levelset.Less(SlogLevelName(h.logger.levelset, level), h.logger.level)
+}
+
+// See slog.Handler.Handle
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	tags := make(Tags, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(tags, h.prefix, a)
+		return true
+	})
+
+	h.logger.mu.Lock()
+	level := SlogLevelName(h.logger.levelset, r.Level)
+	h.logger.mu.Unlock()
+
+	return h.logger.write(ctx, r.Time, r.PC, level, r.Message, h.logger.contextTags(ctx, tags))
+}
+
+// See slog.Handler.WithAttrs
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	tags := make(Tags, len(attrs))
+	for _, a := range attrs {
+		addSlogAttr(tags, h.prefix, a)
+	}
+	h2 := *h
+	h2.logger = h.logger.child(tags)
+	return &h2
+}
+
+// See slog.Handler.WithGroup
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// Add a slog attribute to tags, flattening groups.
+func addSlogAttr(tags Tags, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addSlogAttr(tags, prefix, ga)
+		}
+		return
+	}
+
+	key := prefix + a.Key
+	if key == "" {
+		key = "tags"
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		tags.Add(key, a.Value.String())
+	case slog.KindInt64:
+		tags.addValue(key, a.Value.Int64())
+	case slog.KindUint64:
+		tags.addValue(key, a.Value.Uint64())
+	case slog.KindFloat64:
+		tags.addValue(key, a.Value.Float64())
+	case slog.KindBool:
+		tags.addValue(key, a.Value.Bool())
+	case slog.KindDuration:
+		tags.addValue(key, a.Value.Duration())
+	case slog.KindTime:
+		tags.addValue(key, a.Value.Time())
+	default:
+		tags.addValue(key, a.Value.Any())
+	}
+}
+
+// Get the name of the level in a LevelSet which corresponds to a slog level.
+// Levels between the slog constants map to NOTICE (between INFO and WARN),
+// CRITICAL (ERROR+4), ALERT (ERROR+8), and EMERGENCY (ERROR+12). If the
+// LevelSet does not contain the preferred name, common aliases are tried
+// (e.g. WARN for WARNING) before falling back to the preferred name.
+func SlogLevelName(ls *LevelSet, level slog.Level) string {
+	var names []string
+	switch {
+	case level < slog.LevelInfo:
+		names = []string{LevelDebug, LevelTrace, LevelFine}
+	case level < slog.LevelInfo+2:
+		names = []string{LevelInfo, LevelDefault}
+	case level < slog.LevelWarn:
+		names = []string{LevelNotice, LevelInfo}
+	case level < slog.LevelError:
+		names = []string{LevelWarning, LevelWarn}
+	case level < slog.LevelError+4:
+		names = []string{LevelError, LevelErr}
+	case level < slog.LevelError+8:
+		names = []string{LevelCritical, LevelFatal, LevelError, LevelErr}
+	case level < slog.LevelError+12:
+		names = []string{LevelAlert, LevelCritical, LevelFatal}
+	default:
+		names = []string{LevelEmergency, LevelAlert, LevelFatal}
+	}
+
+	if ls != nil {
+		for _, name := range names {
+			if ls.Contains(name) {
+				return name
+			}
+		}
+	}
+	return names[0]
+}
+
+// Get the slog level which corresponds to a level name. This is the inverse of
+// SlogLevelName(). Empty and unknown names map to slog.LevelInfo.
+func SlogLevel(level string) slog.Level {
+	switch strings.ToUpper(level) {
+	case LevelTrace, LevelFinest:
+		return slog.LevelDebug - 4
+	case LevelFiner:
+		return slog.LevelDebug - 2
+	case LevelDebug, LevelFine:
+		return slog.LevelDebug
+	case LevelNotice:
+		return slog.LevelInfo + 2
+	case LevelWarning, LevelWarn:
+		return slog.LevelWarn
+	case LevelError, LevelErr:
+		return slog.LevelError
+	case LevelCritical, LevelFatal:
+		return slog.LevelError + 4
+	case LevelAlert:
+		return slog.LevelError + 8
+	case LevelEmergency:
+		return slog.LevelError + 12
+	}
+	return slog.LevelInfo
+}
+
+// Create a new Logger which forwards log lines to a slog.Handler instead of
+// writing them to an io.Writer. Levels are converted with SlogLevel() and tags
+// are passed as attributes. The context given to the Ctx methods is passed to
+// the handler. The formatting parameters have no effect while forwarding.
+// Calling SetOutput() stops forwarding.
+func NewSlogLogger(h slog.Handler) *Logger {
+	tl := New(nil, "", LstdFlags)
+	tl.handler = h
+	return tl
+}
+
+// Forward a log line to a slog.Handler. A nil ctx is replaced with
+// context.Background().
+func forward(ctx context.Context, h slog.Handler, now time.Time, pc uintptr, level string, s string, tags Tags) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	slevel := SlogLevel(level)
+	if !h.Enabled(ctx, slevel) {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	r := slog.NewRecord(now, slevel, s, pc)
+	for _, k := range keys {
+		r.AddAttrs(slogAttr(k, tags[k]))
+	}
+	return h.Handle(ctx, r)
+}
+
+// Convert a tag to a slog attribute. Nested tags are converted to groups.
+func slogAttr(key string, value interface{}) slog.Attr {
+	switch vs := resolveTagValue(value).(type) {
+	case string:
+		return slog.String(key, vs)
+	case Tags:
+		keys := make([]string, 0, len(vs))
+		for k := range vs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]slog.Attr, len(keys))
+		for i, k := range keys {
+			attrs[i] = slogAttr(k, vs[k])
+		}
+		return slog.Attr{Key: key, Value: slog.GroupValue(attrs...)}
+	default:
+		return slog.Any(key, vs)
+	}
+}
@@ -0,0 +1,44 @@
+package taglog
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// Tags of the Logger can be changed while a line is forwarded to a
+// slog.Handler. Run with -race.
+func TestSlogForwardTagsRace(t *testing.T) {
+	logger := NewSlogLogger(slog.NewTextHandler(io.Discard, nil))
+	logger.AddTag("k", "a", "b", "c", "d")
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			logger.PopTag("k")
+			logger.PushTag("k", "z")
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				logger.Printf("line %d", j)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-stopped
+}
@@ -0,0 +1,343 @@
+package taglog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog facilities. See RFC 5424.
+const (
+	FacilityKern = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthpriv
+	FacilityFtp
+)
+
+// Local use syslog facilities. See RFC 5424.
+const (
+	FacilityLocal0 = iota + 16
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// Options for a syslog Encoder. See NewSyslogEncoder.
+type SyslogOptions struct {
+	RFC3164  bool   // use the BSD format instead of RFC 5424
+	Facility int    // defaults to FacilityUser, FacilityKern cannot be used
+	Hostname string // defaults to os.Hostname()
+	AppName  string // used if the prefix is empty, defaults to the program name
+	SDID     string // structured data ID for tags, defaults to "taglog@32473"
+}
+
+type syslogEncoder struct {
+	opts     SyslogOptions
+	hostOnce sync.Once // resolves the default hostname on first use
+}
+
+// Create an Encoder for syslog messages. The priority is calculated from the
+// facility and the syslog severity of the level, see LevelSet.Severity. The
+// prefix is used as the app-name, without surrounding spaces and colons. In
+// RFC 5424 format tags are written as a single structured data element, and
+// in RFC 3164 format they are written before the message as in plain format.
+// The registered formats FormatSyslog5424 and FormatSyslog3164 use the
+// default options; use RegisterFormat() for other options.
+func NewSyslogEncoder(opts SyslogOptions) Encoder {
+	if opts.Facility == FacilityKern {
+		opts.Facility = FacilityUser
+	}
+	if opts.AppName == "" {
+		opts.AppName = filepath.Base(os.Args[0])
+	}
+	if opts.SDID == "" {
+		opts.SDID = "taglog@32473"
+	}
+	return &syslogEncoder{opts: opts}
+}
+
+// Get the hostname, looking up the default the first time it is needed.
+func (enc *syslogEncoder) hostname() string {
+	enc.hostOnce.Do(func() {
+		if enc.opts.Hostname == "" {
+			enc.opts.Hostname, _ = os.Hostname()
+		}
+	})
+	return enc.opts.Hostname
+}
+
+// See Encoder.Encode
+func (enc *syslogEncoder) Encode(b []byte, e *Entry) ([]byte, error) {
+	appName := strings.Trim(e.Params.Prefix, " :")
+	if appName == "" {
+		appName = enc.opts.AppName
+	}
+
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(enc.opts.Facility*8+e.Severity), 10)
+	b = append(b, '>')
+
+	if enc.opts.RFC3164 {
+		return enc.encode3164(b, e, appName)
+	}
+	return enc.encode5424(b, e, appName)
+}
+
+// Encode the rest of an RFC 5424 message after the priority.
+func (enc *syslogEncoder) encode5424(b []byte, e *Entry, appName string) ([]byte, error) {
+	b = append(b, "1 "...)
+	if e.Time.IsZero() {
+		b = append(b, '-')
+	} else {
+		b = e.Time.AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	}
+	b = append(b, ' ')
+	b = appendSyslogHeaderField(b, enc.hostname(), 255)
+	b = append(b, ' ')
+	b = appendSyslogHeaderField(b, appName, 48)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(os.Getpid()), 10)
+	b = append(b, " - "...)
+
+	tags := flattenTags(e.AllTags())
+	if len(tags) == 0 {
+		b = append(b, '-')
+	} else {
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		e.SortKeys(keys)
+
+		b = append(b, '[')
+		b = appendSyslogName(b, enc.opts.SDID)
+		for _, k := range keys {
+			for _, v := range tags.GetAll(k) {
+				b = append(b, ' ')
+				b = appendSyslogName(b, k)
+				b = append(b, '=', '"')
+				b = appendSyslogParamValue(b, v)
+				b = append(b, '"')
+			}
+		}
+		b = append(b, ']')
+	}
+
+	if e.Message != "" {
+		b = append(b, ' ')
+		b = append(b, e.Message...)
+	}
+	return b, nil
+}
+
+// Encode the rest of an RFC 3164 message after the priority.
+func (enc *syslogEncoder) encode3164(b []byte, e *Entry, appName string) ([]byte, error) {
+	ts := e.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	b = ts.AppendFormat(b, time.Stamp)
+	b = append(b, ' ')
+	b = appendSyslogHeaderField(b, enc.hostname(), 255)
+	b = append(b, ' ')
+	b = appendSyslogHeaderField(b, appName, 32)
+	b = append(b, '[')
+	b = strconv.AppendInt(b, int64(os.Getpid()), 10)
+	b = append(b, "]: "...)
+
+	// the rest is the plain format without the prefix and timestamp
+	plain := *e
+	plain.Timestamp = ""
+	plain.Params.Prefix = ""
+	return appendPlainLine(b, &plain, false), nil
+}
+
+// Append a header field, which is limited to printable ASCII without spaces.
+// An empty field is written as "-".
+func appendSyslogHeaderField(b []byte, s string, maxLen int) []byte {
+	if s == "" {
+		return append(b, '-')
+	}
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// Append a structured data ID or parameter name. Characters which are not
+// allowed are replaced with underscores.
+func appendSyslogName(b []byte, s string) []byte {
+	if s == "" {
+		s = "tags"
+	}
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// Append a structured data parameter value, escaping '"', '\', and ']'.
+func appendSyslogParamValue(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' || c == '\\' || c == ']' {
+			b = append(b, '\\')
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// Paths of the local syslog socket on common systems.
+var syslogSocketPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// An io.WriteCloser which sends syslog messages to a syslog server. Each Write
+// is sent as one message. On TCP and other stream connections messages are
+// framed by octet counting (RFC 6587), and on unix stream sockets they are
+// terminated by a newline. If a write fails the connection is reestablished
+// and the write is retried once. It is safe for concurrent use. Use it with
+// FormatSyslog5424 or FormatSyslog3164.
+type SyslogWriter struct {
+	mu      sync.Mutex
+	network string
+	raddr   string
+	conn    net.Conn
+	closed  bool
+}
+
+// Connect to a syslog server. network is "unix", "unixgram", "tcp", "udp",
+// or any other network supported by net.Dial. If network and raddr are empty,
+// the local syslog socket is used.
+func DialSyslog(network, raddr string) (*SyslogWriter, error) {
+	sw := new(SyslogWriter)
+	sw.network = network
+	sw.raddr = raddr
+	err := sw.connect()
+	if err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// The caller must hold the lock.
+func (sw *SyslogWriter) connect() error {
+	if sw.conn != nil {
+		sw.conn.Close()
+		sw.conn = nil
+	}
+
+	if sw.network == "" && sw.raddr == "" {
+		for _, path := range syslogSocketPaths {
+			for _, network := range []string{"unixgram", "unix"} {
+				conn, err := net.Dial(network, path)
+				if err == nil {
+					sw.conn = conn
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("Unix syslog delivery error")
+	}
+
+	conn, err := net.Dial(sw.network, sw.raddr)
+	if err != nil {
+		return err
+	}
+	sw.conn = conn
+	return nil
+}
+
+// Send a message. See io.Writer.
+func (sw *SyslogWriter) Write(b []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.closed {
+		return 0, os.ErrClosed
+	}
+
+	msg := b
+	if len(msg) > 0 && msg[len(msg)-1] == '\n' {
+		msg = msg[:len(msg)-1]
+	}
+
+	if sw.conn != nil {
+		err := sw.send(msg)
+		if err == nil {
+			return len(b), nil
+		}
+	}
+	err := sw.connect()
+	if err != nil {
+		return 0, err
+	}
+	err = sw.send(msg)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// The caller must hold the lock.
+func (sw *SyslogWriter) send(msg []byte) error {
+	var frame []byte
+	switch sw.conn.RemoteAddr().Network() {
+	case "udp", "udp4", "udp6", "unixgram":
+		frame = msg
+	case "unix":
+		frame = make([]byte, 0, len(msg)+1)
+		frame = append(frame, msg...)
+		frame = append(frame, '\n')
+	default:
+		frame = make([]byte, 0, len(msg)+8)
+		frame = strconv.AppendInt(frame, int64(len(msg)), 10)
+		frame = append(frame, ' ')
+		frame = append(frame, msg...)
+	}
+	_, err := sw.conn.Write(frame)
+	return err
+}
+
+// Close the connection.
+func (sw *SyslogWriter) Close() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.closed = true
+	if sw.conn == nil {
+		return nil
+	}
+	err := sw.conn.Close()
+	sw.conn = nil
+	return err
+}
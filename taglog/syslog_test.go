@@ -0,0 +1,121 @@
+package taglog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Check that a message has the expected header and ends with rest. The
+// timestamp is not checked.
+func checkSyslogMessage(t *testing.T, msg string, header string, rest string) {
+	t.Helper()
+	if !strings.HasPrefix(msg, header) || !strings.HasSuffix(msg, rest) {
+		t.Errorf("message %q: want %q ... %q", msg, header, rest)
+	}
+}
+
+// Write two lines through a SyslogWriter, closing its connection in between
+// so the second write reconnects.
+func writeSyslogLines(t *testing.T, sw *SyslogWriter, format int) {
+	t.Helper()
+	logger := New(sw, "app: ", LstdFlags)
+	logger.SetFormat(format)
+	logger.Lprintw("WARNING", "first", "k", "v")
+
+	sw.mu.Lock()
+	sw.conn.Close()
+	sw.mu.Unlock()
+
+	logger.Lprintw("ERROR", "second", "k", `a"b]`)
+}
+
+func TestSyslogUnixgram(t *testing.T) {
+	dir, err := os.MkdirTemp("", "taglog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sw, err := DialSyslog("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sw.Close()
+	writeSyslogLines(t, sw, FormatSyslog5424)
+
+	hostname, _ := os.Hostname()
+	header := " " + hostname + " app " + strconv.Itoa(os.Getpid()) + " - "
+	want := []struct {
+		header, rest string
+	}{
+		{"<12>1 ", header + `[taglog@32473 k="v" level="WARNING"] first`},
+		{"<11>1 ", header + `[taglog@32473 k="a\"b\]" level="ERROR"] second`},
+	}
+	buf := make([]byte, 4096)
+	for _, w := range want {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSyslogMessage(t, string(buf[:n]), w.header, w.rest)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	sw, err := DialSyslog("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sw.Close()
+	writeSyslogLines(t, sw, FormatSyslog3164)
+
+	hostname, _ := os.Hostname()
+	header := " " + hostname + " app[" + strconv.Itoa(os.Getpid()) + "]: "
+	want := []struct {
+		header, rest string
+	}{
+		{"<12>", header + "[k=v] [level=WARNING] first"},
+		{"<11>", header + `[k=a"b\]] [level=ERROR] second`},
+	}
+	// each connection carries one message framed by octet counting
+	for _, w := range want {
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := bufio.NewReader(conn)
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+		if err != nil {
+			t.Fatalf("frame length %q: %v", size, err)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+		checkSyslogMessage(t, string(msg), w.header, w.rest)
+	}
+}
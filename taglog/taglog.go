@@ -1,28 +1,36 @@
 package taglog
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
-	"sort"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
 const (
-	FormatPlain = iota // Plain log format. Simple format for easy human reading.
-	FormatJSON         // JSON log format. Each log line is a JSON blob for easy machine reading.
+	FormatPlain      = iota // Plain log format. Simple format for easy human reading.
+	FormatJSON              // JSON log format. Each log line is a JSON blob for easy machine reading.
+	FormatLogfmt            // logfmt log format. Each log line is a list of key=value pairs.
+	FormatSyslog5424        // RFC 5424 syslog format. Tags are written as structured data. See DialSyslog.
+	FormatSyslog3164        // RFC 3164 (BSD) syslog format. Tags are written as in plain format. See DialSyslog.
 )
 
-// Get a log format from a string.
+// Get a log format from a string. The string is the name of a predefined or
+// registered format, optionally with a "Format" prefix (e.g. "FormatJSON" or
+// "json"). See RegisterFormat.
 func ParseFormat(fmt string) int {
-	switch strings.ToLower(fmt) {
-	case "formatplain":
-		return FormatPlain
-	case "formatjson":
-		return FormatJSON
+	name := strings.ToLower(fmt)
+	if format, found := lookupFormatName(name); found {
+		return format
+	}
+	if format, found := lookupFormatName(strings.TrimPrefix(name, "format")); found {
+		return format
 	}
 	return -1
 }
@@ -81,8 +89,8 @@ const (
 	Ldate         = 1 << iota     // the date
 	Ltime                         // the time
 	Lmicroseconds                 // microsecond resolution: 01:23:23.123123.  assumes Ltime.
-	Llongfile                     // IGNORED
-	Lshortfile                    // IGNORED
+	Llongfile                     // full file name and line number: /a/b/c/d.go:23
+	Lshortfile                    // final file name element and line number: d.go:23. overrides Llongfile
 	LUTC                          // if Ldate or Ltime is set, use UTC rather than the local time zone
 	LstdFlags     = Ldate | Ltime // initial values for the standard logger
 
@@ -127,21 +135,38 @@ type Params struct {
 	Flag                int
 }
 
-// taglog counterpart to the log.Logger type
-type Logger struct {
+// State shared by a Logger and all of the child Loggers derived from it.
+type loggerCore struct {
 	mu            sync.Mutex
-	tags          Tags
 	levelset      *LevelSet
 	level         string
 	levelTag      string
 	standardLevel string
+	callerTag     string
+	callerSkip    int
+	stackLevel    string // see SetStackLevel
 	out           io.Writer
+	handler       slog.Handler // when set, lines are forwarded here instead of out
+	extractors    []ContextExtractor
+	async         *asyncWriter // when set, lines are queued instead of written directly
 	params        Params
+	order         tagOrder
+	tagGen        uint64 // incremented when the tags of any Logger sharing the core change
+}
+
+// taglog counterpart to the log.Logger type
+type Logger struct {
+	*loggerCore
+	parent *Logger // tags of the parent are inherited, see With()
+	tags   Tags
+	keys   []string // keys of tags in the order they were added
+	cache  *tagCache
 }
 
 // See log.New
 func New(out io.Writer, prefix string, flag int) *Logger {
 	tl := new(Logger)
+	tl.loggerCore = new(loggerCore)
 	tl.tags = make(Tags)
 	tl.out = out
 	tl.params = DefaultParams
@@ -149,6 +174,7 @@ func New(out io.Writer, prefix string, flag int) *Logger {
 	tl.params.Flag = flag
 	tl.DefineLevels(DefaultLevelSet)
 	tl.levelTag = "level"
+	tl.callerTag = "caller"
 	return tl
 }
 
@@ -170,17 +196,133 @@ func Global() *Logger {
 // Create a new Logger by copying the formatting and tags from another Logger.
 func (this *Logger) Copy() *Logger {
 	this.mu.Lock()
-	tl := *this
+	defer this.mu.Unlock()
+	tl := new(Logger)
+	tl.loggerCore = &loggerCore{
+		levelset:      this.levelset,
+		level:         this.level,
+		levelTag:      this.levelTag,
+		standardLevel: this.standardLevel,
+		callerTag:     this.callerTag,
+		callerSkip:    this.callerSkip,
+		stackLevel:    this.stackLevel,
+		out:           this.out,
+		handler:       this.handler,
+		extractors:    this.extractors,
+		params:        this.params,
+		order:         this.order,
+	}
 
-	// deep copy tags
-	tl.tags = make(Tags)
-	for k, v := range this.tags {
-		tl.tags[k] = v
+	// deep copy tags, flattening any inherited tags
+	tl.tags = this.lineTags(nil).Copy()
+	tl.keys = syncKeys(append([]string(nil), this.tagKeys()...), tl.tags)
+
+	return tl
+}
+
+// Create a child Logger with one or more values for a key. The child shares
+// the output, formatting parameters, and level of this Logger, and inherits
+// its tags. Tags on the child replace inherited tags with the same key, except
+// for global tags, which are appended to the inherited global tags. Changing
+// the tags of the child does not affect this Logger.
+func (this *Logger) With(key string, value ...string) *Logger {
+	if key == "" {
+		key = "tags"
 	}
+	tags := make(Tags)
+	tags.Add(key, value...)
+	return this.child(tags)
+}
 
-	tl.mu.Unlock()
-	this.mu.Unlock()
-	return &tl
+// Create a child Logger with a set of tags. The tags are copied. See With().
+func (this *Logger) WithTags(tags Tags) *Logger {
+	childTags := tags.Copy()
+	if _, found := childTags[""]; found {
+		childTags.Add("tags", childTags.GetAll("")...)
+		childTags.Del("")
+	}
+	return this.child(childTags)
+}
+
+func (this *Logger) child(tags Tags) *Logger {
+	tl := new(Logger)
+	tl.loggerCore = this.loggerCore
+	tl.parent = this
+	tl.tags = tags
+	tl.keys = syncKeys(nil, tags)
+	return tl
+}
+
+// Collect the tags for a log line from this Logger, its ancestors, and the
+// per-line tags. The result is a new Tags map which can be modified freely.
+// The caller must hold the lock.
+func (this *Logger) lineTags(extra Tags) Tags {
+	var out Tags
+	if this.parent != nil {
+		out = this.parent.lineTags(nil)
+	} else {
+		out = make(Tags, len(this.tags)+len(extra))
+	}
+	layerTags(out, this.tags)
+	layerTags(out, extra)
+	return out
+}
+
+// Get the tags visible to this Logger, including inherited tags. The result
+// must not be modified. The caller must hold the lock.
+func (this *Logger) visibleTags() Tags {
+	if this.parent == nil {
+		return this.tags
+	}
+	return this.lineTags(nil)
+}
+
+// Layer tags on top of existing tags. Keys in the top layer replace keys in
+// the bottom layer, except for global tags which are appended.
+func layerTags(bottom Tags, top Tags) {
+	for k, v := range top {
+		if k == "tags" {
+			current := bottom.GetAll(k)
+			values := make([]string, 0, len(current)+len(top.GetAll(k)))
+			values = append(values, current...)
+			values = append(values, top.GetAll(k)...)
+			bottom.Set(k, values...)
+		} else {
+			bottom[k] = v
+		}
+	}
+}
+
+// Convert alternating keys and values to Tags. Values may be a string, a
+// []string, or any of the typed values accepted by Tags.SetValue(). A trailing
+// key without a value is added as a global tag. Tags attached to error values
+// with TagError() are included, and replaced by the keys and values.
+func kvTags(kv []interface{}) Tags {
+	if len(kv) == 0 {
+		return nil
+	}
+	tags := make(Tags, len(kv)/2)
+	var errTags []interface{}
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			tags.Add("tags", fmt.Sprint(kv[i]))
+			break
+		}
+		key := fmt.Sprint(kv[i])
+		if key == "" {
+			key = "tags"
+		}
+		tags.addValue(key, kv[i+1])
+		if _, ok := kv[i+1].(error); ok {
+			errTags = append(errTags, kv[i+1])
+		}
+	}
+
+	if out := argTags(errTags); out != nil {
+		layerTags(out, tags)
+		return out
+	}
+	return tags
 }
 
 // Generate the timestamp format from the type and flags
@@ -241,88 +383,179 @@ func calcTsFormat(params *Params) string {
 	return params.TimestampFormat
 }
 
+// Format the caller of a log line according to the Llongfile and Lshortfile
+// flags. An empty string is returned if neither flag is set.
+func formatCaller(flag int, pc uintptr) string {
+	if flag&(Lshortfile|Llongfile) == 0 {
+		return ""
+	}
+	file := "???"
+	line := 0
+	if pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if frame.File != "" {
+			file = frame.File
+			line = frame.Line
+		}
+	}
+	if flag&Lshortfile != 0 {
+		if i := strings.LastIndexByte(file, '/'); i >= 0 {
+			file = file[i+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(line)
+}
+
 // See log.Logger.Output
 func (this *Logger) Output(s string) error {
-	return this.Loutput(this.standardLevel, s)
+	return this.LoutputDepth(2, this.standardLevel, s)
 }
 
 // See log.Logger.Output
 func (this *Logger) Loutput(level string, s string) error {
+	return this.LoutputDepth(2, level, s)
+}
+
+// Same as Output, but calldepth is the number of stack frames to skip when
+// determining the caller for Llongfile and Lshortfile. A calldepth of 1
+// refers to the caller of OutputDepth. See log.Logger.Output
+func (this *Logger) OutputDepth(calldepth int, s string) error {
+	return this.LoutputDepth(calldepth+1, this.standardLevel, s)
+}
+
+// Same as Loutput, but calldepth is the number of stack frames to skip when
+// determining the caller for Llongfile and Lshortfile. A calldepth of 1
+// refers to the caller of LoutputDepth. See log.Logger.Output
+func (this *Logger) LoutputDepth(calldepth int, level string, s string) error {
+	return this.output(calldepth+1, level, s, nil)
+}
+
+// Write a log line with additional per-line tags. calldepth is relative to the
+// caller of output.
+func (this *Logger) output(calldepth int, level string, s string, extra Tags) error {
+	return this.outputCtx(calldepth+1, nil, level, s, extra)
+}
+
+// Write a log line for a given time and program counter. A zero time omits the
+// timestamp. A zero program counter reports an unknown caller. ctx is passed
+// to the slog.Handler when forwarding, and may be nil.
+func (this *Logger) write(ctx context.Context, now time.Time, pc uintptr, level string, s string, extra Tags) error {
+	return this.writeCaller(ctx, now, pc, "", level, s, extra)
+}
+
+// Same as write, but with a formatted caller, which is used instead of pc when
+// it is not empty.
+func (this *Logger) writeCaller(ctx context.Context, now time.Time, pc uintptr, caller string, level string, s string, extra Tags) error {
 	var err error
-	var b []byte
 
-	now := time.Now()
+	this.mu.Lock()
+	if this.handler != nil {
+		// the handler is called without holding the lock since it may block
+		// or log through this Logger
+		handler := this.handler
+		if !this.enabled(level) {
+			this.mu.Unlock()
+			return nil
+		}
+		if this.params.Flag&(LUTC) != 0 {
+			now = now.UTC()
+		}
+		// the values may share their backing arrays with the tags of the
+		// Logger, which can change once the lock is released
+		tags, _ := this.writeTags(extra)
+		tags = tags.Copy()
+		this.mu.Unlock()
+		return forward(ctx, handler, now, pc, level, s, tags)
+	}
+	defer this.mu.Unlock()
+
 	if this.params.Flag&(LUTC) != 0 {
 		now = now.UTC()
 	}
-	this.mu.Lock()
-	defer this.mu.Unlock()
+
+	if caller == "" || this.params.Flag&(Lshortfile|Llongfile) == 0 {
+		caller = formatCaller(this.params.Flag, pc)
+	}
 
 	tsFormat := calcTsFormat(&this.params)
-	nowStr := now.Format(tsFormat)
+	var nowStr string
+	if !now.IsZero() {
+		nowStr = now.Format(tsFormat)
+	}
 
-	if level != "" && this.levelset != nil && this.level != "" {
-		// discard messages lower than the current log level
-		if this.levelset.Less(level, this.level) {
-			return nil
-		}
+	// discard messages lower than the current log level
+	if !this.enabled(level) {
+		return nil
+	}
 
-		// set level tag
-		if this.levelTag != "" {
-			if this.levelset.Contains(level) {
-				this.tags.Set(this.levelTag, strings.ToUpper(level))
-				defer this.tags.Del(this.levelTag)
-			}
-		}
+	tags, cache := this.writeTags(extra)
+
+	e := entryPool.Get().(*Entry)
+	defer putEntry(e)
+	*e = Entry{
+		Time:      now,
+		Timestamp: nowStr,
+		Level:     strings.ToUpper(level),
+		Severity:  SeverityInfo,
+		Message:   s,
+		Caller:    caller,
+		CallerTag: this.callerTag,
+		Tags:      tags,
+		Params:    this.params,
+		cache:     cache,
+	}
+	if cache != nil {
+		e.order = cache.order
+	} else {
+		e.order = this.lineOrder()
 	}
 
-	if this.params.Format == FormatJSON {
-		if nowStr != "" {
-			this.tags.Set("timestamp", nowStr)
-		}
-		this.tags.Set("msg", s)
+	if this.levelset != nil {
+		e.Severity = this.levelset.Severity(level)
+	}
 
-		b, err = json.Marshal(&this.tags)
-		if err != nil {
-			return err
-		}
-	} else if this.params.Format == FormatPlain {
-		line := []string{}
-		if nowStr != "" {
-			line = append(line, nowStr)
-		}
-		lineTags := []string{}
-		for k, v := range this.tags {
-			switch vs := v.(type) {
-			case string:
-				if k == "tags" {
-					lineTags = append(lineTags, fmt.Sprintf("[%s]", vs))
-				} else {
-					lineTags = append(lineTags, fmt.Sprintf("[%s=%s]", k, vs))
-				}
-			case []string:
-				if k == "tags" {
-					for _, v0 := range vs {
-						lineTags = append(lineTags, fmt.Sprintf("[%s]", v0))
-					}
-				} else {
-					lineTags = append(lineTags, fmt.Sprintf("[%s=%s]", k, strings.Join(vs, ",")))
-				}
-			}
-		}
-		sort.Strings(lineTags)
-		line = append(line, lineTags...)
-		if s != "" {
-			line = append(line, s)
+	// set level tag
+	if level != "" && this.levelset != nil && this.level != "" && this.levelTag != "" {
+		if this.levelset.Contains(level) {
+			e.LevelTag = this.levelTag
 		}
-		b = []byte(this.params.Prefix + strings.Join(line, " "))
 	}
 
-	b = append(b, '\n')
-	_, err = this.out.Write(b)
+	enc := FormatEncoder(this.params.Format)
+	if enc == nil {
+		return fmt.Errorf("Invalid format")
+	}
+	buf := getBuffer()
+	*buf, err = enc.Encode(*buf, e)
+	if err != nil {
+		putBuffer(buf)
+		return err
+	}
+
+	*buf = append(*buf, '\n')
+	if this.async != nil {
+		// the queue takes ownership of the buffer
+		below := level != "" && this.levelset != nil && this.async.opts.Level != "" &&
+			this.levelset.Less(level, this.async.opts.Level)
+		this.async.enqueue(this.out, buf, below)
+		return nil
+	}
+	_, err = this.out.Write(*buf)
+	putBuffer(buf)
 	return err
 }
 
+// Get the tags of a line with per-line tags, and the cache if there are none.
+// The tags must not be modified. The caller must hold the lock.
+func (this *Logger) writeTags(extra Tags) (Tags, *tagCache) {
+	if extra == nil {
+		return this.staticTags()
+	}
+	tags := this.lineTags(extra)
+	resolveTags(tags)
+	return tags, nil
+}
+
 // Get the formatting parameters.
 func (this *Logger) Params() Params {
 	this.mu.Lock()
@@ -400,9 +633,10 @@ func (this *Logger) TimestampFormat() string {
 func (this *Logger) SetFormat(format int) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
-	if this.params.Format == FormatJSON && format == FormatPlain {
+	if this.params.Format == FormatJSON && format != FormatJSON {
 		this.tags.Del("timestamp")
 		this.tags.Del("msg")
+		this.tagsChanged()
 	}
 	this.params.Format = format
 }
@@ -414,10 +648,36 @@ func (this *Logger) Format() int {
 	return this.params.Format
 }
 
+// Set the tag used for the caller in JSON format when Llongfile or Lshortfile
+// is set. An empty string omits the caller from JSON output.
+func (this *Logger) SetCallerTag(tag string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.callerTag = tag
+}
+
+// Set the number of additional stack frames to skip when determining the
+// caller. This allows wrappers around a Logger to report their own callers
+// rather than themselves.
+func (this *Logger) SetCallerSkip(skip int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.callerSkip = skip
+}
+
+// Get the number of additional stack frames skipped when determining the
+// caller.
+func (this *Logger) CallerSkip() int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.callerSkip
+}
+
 // Add one or more values to a key.
 func (this *Logger) AddTag(key string, value ...string) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	if key == "" {
 		key = "tags"
 	}
@@ -428,6 +688,7 @@ func (this *Logger) AddTag(key string, value ...string) {
 func (this *Logger) MergeTag(key string, value ...string) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	if key == "" {
 		key = "tags"
 	}
@@ -439,6 +700,7 @@ func (this *Logger) MergeTag(key string, value ...string) {
 func (this *Logger) PushTag(key string, value ...string) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	if key == "" {
 		key = "tags"
 	}
@@ -449,6 +711,7 @@ func (this *Logger) PushTag(key string, value ...string) {
 func (this *Logger) PopTag(key string) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	if key == "" {
 		key = "tags"
 	}
@@ -459,12 +722,36 @@ func (this *Logger) PopTag(key string) {
 func (this *Logger) SetTag(key string, value ...string) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	if key == "" {
 		key = "tags"
 	}
 	this.tags.Set(key, value...)
 }
 
+// Set a single typed value for a key. Any existing values are discarded. See
+// Tags.SetValue() for the supported types.
+func (this *Logger) SetTagValue(key string, value interface{}) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	defer this.tagsChanged()
+	if key == "" {
+		key = "tags"
+	}
+	this.tags.SetValue(key, value)
+}
+
+// Get the value for a key as stored, which may be a typed value. If the key
+// does not exist, nil is returned.
+func (this *Logger) GetTagValue(key string) interface{} {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if key == "" {
+		key = "tags"
+	}
+	return this.visibleTags().GetValue(key)
+}
+
 // Get the first value for a key. If the key does not exist, an empty string is
 // returned.
 func (this *Logger) GetTag(key string) string {
@@ -473,7 +760,7 @@ func (this *Logger) GetTag(key string) string {
 	if key == "" {
 		key = "tags"
 	}
-	return this.tags.Get(key)
+	return this.visibleTags().Get(key)
 }
 
 // Get all the values for a key. If the key does not exist, a nil slice is
@@ -484,13 +771,14 @@ func (this *Logger) GetTags(key string) []string {
 	if key == "" {
 		key = "tags"
 	}
-	return this.tags.GetAll(key)
+	return this.visibleTags().GetAll(key)
 }
 
 // Delete a key.
 func (this *Logger) DelTag(key string) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	if key == "" {
 		this.tags.Del("tags")
 	}
@@ -501,6 +789,7 @@ func (this *Logger) DelTag(key string) {
 func (this *Logger) DelTags() {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	this.tags.DelAll()
 }
 
@@ -508,21 +797,24 @@ func (this *Logger) DelTags() {
 func (this *Logger) ExportTags() map[string][]string {
 	this.mu.Lock()
 	defer this.mu.Unlock()
-	return this.tags.Export()
+	return this.visibleTags().Export()
 }
 
 // Import tags from a map of string slices.
 func (this *Logger) ImportTags(tags map[string][]string) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	this.tags.Import(tags)
 }
 
-// Set the output Writer.
+// Set the output Writer. This stops forwarding to a slog.Handler for Loggers
+// created with NewSlogLogger().
 func (this *Logger) SetOutput(w io.Writer) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
 	this.out = w
+	this.handler = nil
 }
 
 // Get the output Writer.
@@ -536,6 +828,7 @@ func (this *Logger) GetOutput() io.Writer {
 func (this *Logger) ParseTags(tags []string) {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	defer this.tagsChanged()
 	for _, s := range tags {
 		ss := strings.Split(s, "=")
 		if len(ss) == 1 {
@@ -554,82 +847,125 @@ func (this *Logger) ParseTags(tags []string) {
 
 // See log.Logger.Printf
 func (this *Logger) Printf(format string, v ...interface{}) {
-	this.Output(fmt.Sprintf(format, v...))
+	if !this.Enabled(this.standardLevel) {
+		return
+	}
+	this.output(2, this.standardLevel, fmt.Sprintf(format, v...), argTags(v))
 }
 
 // See log.Logger.Print
 func (this *Logger) Print(v ...interface{}) {
-	this.Output(fmt.Sprint(v...))
+	if !this.Enabled(this.standardLevel) {
+		return
+	}
+	this.output(2, this.standardLevel, fmt.Sprint(v...), argTags(v))
 }
 
 // See log.Logger.Println
 func (this *Logger) Println(v ...interface{}) {
-	this.Output(fmt.Sprint(v...))
+	if !this.Enabled(this.standardLevel) {
+		return
+	}
+	this.output(2, this.standardLevel, fmt.Sprint(v...), argTags(v))
 }
 
 func (this *Logger) Lprintf(level string, format string, v ...interface{}) {
-	this.Loutput(level, fmt.Sprintf(format, v...))
+	if !this.Enabled(level) {
+		return
+	}
+	this.output(2, level, fmt.Sprintf(format, v...), argTags(v))
 }
 
 func (this *Logger) Lprint(level string, v ...interface{}) {
-	this.Loutput(level, fmt.Sprint(v...))
+	if !this.Enabled(level) {
+		return
+	}
+	this.output(2, level, fmt.Sprint(v...), argTags(v))
 }
 
 func (this *Logger) Lprintln(level string, v ...interface{}) {
-	this.Loutput(level, fmt.Sprint(v...))
+	if !this.Enabled(level) {
+		return
+	}
+	this.output(2, level, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message with per-line tags given as alternating keys and values.
+// The tags are only added to this line. See With() for how they are combined
+// with the tags of the Logger.
+func (this *Logger) Printw(msg string, kv ...interface{}) {
+	if !this.Enabled(this.standardLevel) {
+		return
+	}
+	this.output(2, this.standardLevel, msg, kvTags(kv))
+}
+
+// Print a message at a level with per-line tags given as alternating keys and
+// values. See Printw().
+func (this *Logger) Lprintw(level string, msg string, kv ...interface{}) {
+	if !this.Enabled(level) {
+		return
+	}
+	this.output(2, level, msg, kvTags(kv))
 }
 
 // See log.Logger.Fatal
 func (this *Logger) Fatal(v ...interface{}) {
-	this.Output(fmt.Sprint(v...))
+	this.output(2, this.standardLevel, fmt.Sprint(v...), argTags(v))
+	this.Flush()
 	os.Exit(1)
 }
 
 // See log.Logger.Fatalf
 func (this *Logger) Fatalf(format string, v ...interface{}) {
-	this.Output(fmt.Sprintf(format, v...))
+	this.output(2, this.standardLevel, fmt.Sprintf(format, v...), argTags(v))
+	this.Flush()
 	os.Exit(1)
 }
 
 // See log.Logger.Fatalln
 func (this *Logger) Fatalln(v ...interface{}) {
-	this.Output(fmt.Sprintln(v...))
+	this.output(2, this.standardLevel, fmt.Sprintln(v...), argTags(v))
+	this.Flush()
 	os.Exit(1)
 }
 
 func (this *Logger) Lfatal(level string, v ...interface{}) {
-	this.Loutput(level, fmt.Sprint(v...))
+	this.output(2, level, fmt.Sprint(v...), argTags(v))
+	this.Flush()
 	os.Exit(1)
 }
 
 func (this *Logger) Lfatalf(level string, format string, v ...interface{}) {
-	this.Loutput(level, fmt.Sprintf(format, v...))
+	this.output(2, level, fmt.Sprintf(format, v...), argTags(v))
+	this.Flush()
 	os.Exit(1)
 }
 
 func (this *Logger) Lfatalln(level string, v ...interface{}) {
-	this.Loutput(level, fmt.Sprintln(v...))
+	this.output(2, level, fmt.Sprintln(v...), argTags(v))
+	this.Flush()
 	os.Exit(1)
 }
 
 // See log.Logger.Panic
 func (this *Logger) Panic(v ...interface{}) {
 	s := fmt.Sprintln(v...)
-	this.Output(s)
+	this.output(2, this.standardLevel, s, argTags(v))
 	panic(s)
 }
 
 // See log.Logger.Panicf
 func (this *Logger) Panicf(format string, v ...interface{}) {
 	s := fmt.Sprintf(format, v...)
-	this.Output(s)
+	this.output(2, this.standardLevel, s, argTags(v))
 	panic(s)
 }
 
 // See log.Logger.Panicln
 func (this *Logger) Panicln(v ...interface{}) {
 	s := fmt.Sprintln(v...)
-	this.Output(s)
+	this.output(2, this.standardLevel, s, argTags(v))
 	panic(s)
 }
 
@@ -638,6 +974,18 @@ func Copy() *Logger {
 	return std.Copy()
 }
 
+// Create a child of the Standard Logger with one or more values for a key. See
+// Logger.With().
+func With(key string, value ...string) *Logger {
+	return std.With(key, value...)
+}
+
+// Create a child of the Standard Logger with a set of tags. See
+// Logger.WithTags().
+func WithTags(tags Tags) *Logger {
+	return std.WithTags(tags)
+}
+
 // See log.SetFlags
 func SetFlags(flag int) {
 	std.SetFlags(flag)
@@ -688,6 +1036,31 @@ func Format() int {
 	return std.Format()
 }
 
+// Set the caller tag for the Standard Logger.
+func SetCallerTag(tag string) {
+	std.SetCallerTag(tag)
+}
+
+// Set the number of additional stack frames skipped by the Standard Logger.
+func SetCallerSkip(skip int) {
+	std.SetCallerSkip(skip)
+}
+
+// Get the number of additional stack frames skipped by the Standard Logger.
+func CallerSkip() int {
+	return std.CallerSkip()
+}
+
+// See log.Output
+func OutputDepth(calldepth int, s string) error {
+	return std.OutputDepth(calldepth+1, s)
+}
+
+// See log.Output
+func LoutputDepth(calldepth int, level string, s string) error {
+	return std.LoutputDepth(calldepth+1, level, s)
+}
+
 // Add one or more values to a key.
 func AddTag(key string, value ...string) {
 	std.AddTag(key, value...)
@@ -714,6 +1087,17 @@ func SetTag(key string, value ...string) {
 	std.SetTag(key, value...)
 }
 
+// Set a single typed value for a key. Any existing values are discarded.
+func SetTagValue(key string, value interface{}) {
+	std.SetTagValue(key, value)
+}
+
+// Get the value for a key as stored, which may be a typed value. If the key
+// does not exist, nil is returned.
+func GetTagValue(key string) interface{} {
+	return std.GetTagValue(key)
+}
+
 // Get the first value for a key. If the key does not exist, an empty string is
 // returned.
 func GetTag(key string) string {
@@ -763,81 +1147,123 @@ func ParseTags(tags []string) {
 
 // See log.Printf
 func Printf(format string, v ...interface{}) {
-	std.Output(fmt.Sprintf(format, v...))
+	if !std.Enabled(std.standardLevel) {
+		return
+	}
+	std.output(2, std.standardLevel, fmt.Sprintf(format, v...), argTags(v))
 }
 
 // See log.Print
 func Print(v ...interface{}) {
-	std.Output(fmt.Sprint(v...))
+	if !std.Enabled(std.standardLevel) {
+		return
+	}
+	std.output(2, std.standardLevel, fmt.Sprint(v...), argTags(v))
 }
 
 // See log.Println
 func Println(v ...interface{}) {
-	std.Output(fmt.Sprint(v...))
+	if !std.Enabled(std.standardLevel) {
+		return
+	}
+	std.output(2, std.standardLevel, fmt.Sprint(v...), argTags(v))
 }
 
 func Lprintf(level string, format string, v ...interface{}) {
-	std.Loutput(level, fmt.Sprintf(format, v...))
+	if !std.Enabled(level) {
+		return
+	}
+	std.output(2, level, fmt.Sprintf(format, v...), argTags(v))
 }
 
 func Lprint(level string, v ...interface{}) {
-	std.Loutput(level, fmt.Sprint(v...))
+	if !std.Enabled(level) {
+		return
+	}
+	std.output(2, level, fmt.Sprint(v...), argTags(v))
 }
 
 func Lprintln(level string, v ...interface{}) {
-	std.Loutput(level, fmt.Sprint(v...))
+	if !std.Enabled(level) {
+		return
+	}
+	std.output(2, level, fmt.Sprint(v...), argTags(v))
+}
+
+// Print a message with per-line tags using the Standard Logger. See
+// Logger.Printw().
+func Printw(msg string, kv ...interface{}) {
+	if !std.Enabled(std.standardLevel) {
+		return
+	}
+	std.output(2, std.standardLevel, msg, kvTags(kv))
+}
+
+// Print a message at a level with per-line tags using the Standard Logger. See
+// Logger.Lprintw().
+func Lprintw(level string, msg string, kv ...interface{}) {
+	if !std.Enabled(level) {
+		return
+	}
+	std.output(2, level, msg, kvTags(kv))
 }
 
 // See log.Fatal
 func Fatal(v ...interface{}) {
-	std.Output(fmt.Sprint(v...))
+	std.output(2, std.standardLevel, fmt.Sprint(v...), argTags(v))
+	std.Flush()
 	os.Exit(1)
 }
 
 // See log.Fatalf
 func Fatalf(format string, v ...interface{}) {
-	std.Output(fmt.Sprintf(format, v...))
+	std.output(2, std.standardLevel, fmt.Sprintf(format, v...), argTags(v))
+	std.Flush()
 	os.Exit(1)
 }
 
 // See log.Fatalln
 func Fatalln(v ...interface{}) {
-	std.Output(fmt.Sprintln(v...))
+	std.output(2, std.standardLevel, fmt.Sprintln(v...), argTags(v))
+	std.Flush()
 	os.Exit(1)
 }
 
 func Lfatal(level string, v ...interface{}) {
-	std.Loutput(level, fmt.Sprint(v...))
+	std.output(2, level, fmt.Sprint(v...), argTags(v))
+	std.Flush()
 	os.Exit(1)
 }
 
 func Lfatalf(level string, format string, v ...interface{}) {
-	std.Loutput(level, fmt.Sprintf(format, v...))
+	std.output(2, level, fmt.Sprintf(format, v...), argTags(v))
+	std.Flush()
 	os.Exit(1)
 }
 
 func Lfatalln(level string, v ...interface{}) {
-	std.Loutput(level, fmt.Sprintln(v...))
+	std.output(2, level, fmt.Sprintln(v...), argTags(v))
+	std.Flush()
 	os.Exit(1)
 }
 
 // See log.Panic
 func Panic(v ...interface{}) {
 	s := fmt.Sprintln(v...)
-	std.Output(s)
+	std.output(2, std.standardLevel, s, argTags(v))
 	panic(s)
 }
 
 // See log.Panicf
 func Panicf(format string, v ...interface{}) {
 	s := fmt.Sprintf(format, v...)
-	std.Output(s)
+	std.output(2, std.standardLevel, s, argTags(v))
 	panic(s)
 }
 
 // See log.Panicln
 func Panicln(v ...interface{}) {
 	s := fmt.Sprintln(v...)
-	std.Output(s)
+	std.output(2, std.standardLevel, s, argTags(v))
 	panic(s)
 }
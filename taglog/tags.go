@@ -1,10 +1,39 @@
 package taglog
 
-// A map type specific to tags. The value type must be either string or []string.
-// Users should avoid modifying the map directly and instead use the provided
-// functions.
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A map type specific to tags. Values are usually a string or []string, but
+// SetValue() also accepts typed values: int64, float64, bool, time.Time,
+// time.Duration, nested Tags, and values implementing Marshaler. Other integer
+// and float types are converted to int64 and float64, and maps with string keys
+// are converted to nested Tags. A func() string is called lazily, only for
+// lines which are written. Typed values are written natively in JSON
+// format and formatted as strings elsewhere. Users should avoid modifying the
+// map directly and instead use the provided functions.
 type Tags map[string]interface{}
 
+// A value which converts itself to a tag value when a line is written.
+// MarshalTag returns a string, []string, or any of the typed values accepted by
+// Tags.SetValue().
+type Marshaler interface {
+	MarshalTag() interface{}
+}
+
+// A lazily evaluated tag value.
+type lazyTagValue func() string
+
+// See Marshaler.MarshalTag
+func (f lazyTagValue) MarshalTag() interface{} {
+	return f()
+}
+
 // Add one or more values to a key.
 func (t Tags) Add(key string, value ...string) {
 	for _, v := range value {
@@ -15,10 +44,43 @@ func (t Tags) Add(key string, value ...string) {
 			t[key] = []string{vs, v}
 		case []string:
 			t[key] = append(vs, v)
+		default:
+			t[key] = []string{formatTagValue(vs), v}
+		}
+	}
+}
+
+// Set a single typed value for a key. Any existing values are discarded. See
+// Tags for the supported types. Strings and string slices behave the same as
+// with Set().
+func (t Tags) SetValue(key string, value interface{}) {
+	delete(t, key)
+	t.addValue(key, value)
+}
+
+// Add a typed value to a key. If the key already has values, the value is
+// added as a string.
+func (t Tags) addValue(key string, value interface{}) {
+	switch vs := value.(type) {
+	case string:
+		t.Add(key, vs)
+	case []string:
+		t.Add(key, vs...)
+	default:
+		if _, found := t[key]; found {
+			t.Add(key, formatTagValue(vs))
+		} else {
+			t[key] = tagValue(vs)
 		}
 	}
 }
 
+// Get the value for a key as stored, which may be a typed value. If the key
+// does not exist, nil is returned.
+func (t Tags) GetValue(key string) interface{} {
+	return t[key]
+}
+
 // Add one or more values to a key, merging any duplicate values.
 func (t Tags) Merge(key string, value ...string) {
 	for _, v := range value {
@@ -47,8 +109,6 @@ func (t Tags) Pop(key string) {
 	switch vs := t[key].(type) {
 	case nil:
 		return
-	case string:
-		delete(t, key)
 	case []string:
 		if len(vs) <= 1 {
 			delete(t, key)
@@ -57,6 +117,8 @@ func (t Tags) Pop(key string) {
 		} else {
 			t[key] = vs[:len(vs)-1]
 		}
+	default:
+		delete(t, key)
 	}
 }
 
@@ -73,9 +135,14 @@ func (t Tags) Get(key string) string {
 	case string:
 		return vs
 	case []string:
+		if len(vs) == 0 {
+			return ""
+		}
 		return vs[0]
+	case nil:
+		return ""
 	}
-	return ""
+	return formatTagValue(t[key])
 }
 
 // Get all the values for a key. If the key does not exist, a nil slice is
@@ -86,8 +153,10 @@ func (t Tags) GetAll(key string) []string {
 		return []string{vs}
 	case []string:
 		return vs
+	case nil:
+		return nil
 	}
-	return nil
+	return []string{formatTagValue(t[key])}
 }
 
 // Delete a key.
@@ -113,6 +182,8 @@ func (t Tags) Export() map[string][]string {
 			ts := make([]string, len(vs))
 			copy(ts, vs)
 			tags[k] = ts
+		default:
+			tags[k] = []string{formatTagValue(vs)}
 		}
 	}
 	return tags
@@ -127,7 +198,206 @@ func (t Tags) Import(tags map[string][]string) {
 
 // Copy tags. Performs a deep copy of all tag values.
 func (t Tags) Copy() Tags {
-	out := make(Tags)
-	out.Import(t.Export())
+	out := make(Tags, len(t))
+	for k, v := range t {
+		switch vs := v.(type) {
+		case []string:
+			out[k] = append([]string(nil), vs...)
+		case Tags:
+			out[k] = vs.Copy()
+		default:
+			out[k] = vs
+		}
+	}
 	return out
 }
+
+// Convert a value to one of the types stored in Tags.
+func tagValue(v interface{}) interface{} {
+	switch vs := v.(type) {
+	case nil, string, []string, int64, float64, bool, time.Time, time.Duration, Marshaler:
+		return vs
+	case int:
+		return int64(vs)
+	case int8:
+		return int64(vs)
+	case int16:
+		return int64(vs)
+	case int32:
+		return int64(vs)
+	case uint:
+		return uintTagValue(uint64(vs))
+	case uint8:
+		return int64(vs)
+	case uint16:
+		return int64(vs)
+	case uint32:
+		return int64(vs)
+	case uint64:
+		return uintTagValue(vs)
+	case float32:
+		return float64(vs)
+	case func() string:
+		return lazyTagValue(vs)
+	case Tags:
+		return tagValueMap(vs)
+	case map[string]interface{}:
+		return tagValueMap(vs)
+	case map[string]string:
+		out := make(Tags, len(vs))
+		for k, v := range vs {
+			out[k] = v
+		}
+		return out
+	case []interface{}:
+		if len(vs) == 0 {
+			return nil
+		}
+		values := make([]string, len(vs))
+		for i, v := range vs {
+			values[i] = formatTagValue(tagValue(v))
+		}
+		return values
+	case json.Number:
+		if n, err := vs.Int64(); err == nil {
+			return n
+		}
+		if f, err := vs.Float64(); err == nil {
+			return f
+		}
+		return string(vs)
+	case error:
+		return vs.Error()
+	case fmt.Stringer:
+		return vs.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func uintTagValue(v uint64) interface{} {
+	if v > math.MaxInt64 {
+		return strconv.FormatUint(v, 10)
+	}
+	return int64(v)
+}
+
+func tagValueMap(m map[string]interface{}) Tags {
+	out := make(Tags, len(m))
+	for k, v := range m {
+		out[k] = tagValue(v)
+	}
+	return out
+}
+
+// Resolve a Marshaler to its tag value. Other values are returned as-is.
+func resolveTagValue(v interface{}) interface{} {
+	if m, ok := v.(Marshaler); ok {
+		v = tagValue(m.MarshalTag())
+		if _, ok := v.(Marshaler); ok {
+			return fmt.Sprint(v)
+		}
+	}
+	return v
+}
+
+// Resolve the Marshalers in tags in place, so each is called once per line.
+func resolveTags(t Tags) {
+	for k, v := range t {
+		if _, ok := v.(Marshaler); ok {
+			t[k] = resolveTagValue(v)
+		}
+	}
+}
+
+// Format a tag value as a string. Multiple values are joined with ",", and
+// nested tags are formatted as JSON.
+func formatTagValue(v interface{}) string {
+	switch vs := resolveTagValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return vs
+	case []string:
+		return strings.Join(vs, ",")
+	case int64:
+		return strconv.FormatInt(vs, 10)
+	case float64:
+		return formatFloat(vs)
+	case bool:
+		return strconv.FormatBool(vs)
+	case time.Time:
+		return vs.Format(time.RFC3339Nano)
+	case time.Duration:
+		return vs.String()
+	case Tags:
+		b, err := json.Marshal(jsonTagValue(vs))
+		if err != nil {
+			return fmt.Sprint(map[string]interface{}(vs))
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(vs)
+	}
+}
+
+// Format a float the same way as encoding/json.
+func formatFloat(f float64) string {
+	abs := math.Abs(f)
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Convert a tag value for encoding with encoding/json. Durations are written as
+// integer nanoseconds, and floats which cannot be represented in JSON are
+// written as strings.
+func jsonTagValue(v interface{}) interface{} {
+	switch vs := resolveTagValue(v).(type) {
+	case float64:
+		if math.IsNaN(vs) || math.IsInf(vs, 0) {
+			return formatFloat(vs)
+		}
+		return vs
+	case time.Duration:
+		return int64(vs)
+	case Tags:
+		out := make(map[string]interface{}, len(vs))
+		for k, v := range vs {
+			out[k] = jsonTagValue(v)
+		}
+		return out
+	default:
+		return vs
+	}
+}
+
+// Flatten nested tags into keys joined with "." and resolve Marshalers. The
+// tags are returned as-is if there is nothing to flatten or resolve.
+func flattenTags(t Tags) Tags {
+	flat := true
+	for _, v := range t {
+		switch v.(type) {
+		case Tags, Marshaler:
+			flat = false
+		}
+	}
+	if flat {
+		return t
+	}
+
+	out := make(Tags, len(t))
+	flattenTagsInto(out, "", t)
+	return out
+}
+
+func flattenTagsInto(out Tags, prefix string, t Tags) {
+	for k, v := range t {
+		v = resolveTagValue(v)
+		if nested, ok := v.(Tags); ok {
+			flattenTagsInto(out, prefix+k+".", nested)
+		} else {
+			out[prefix+k] = v
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package taglog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Convert input written with fromParams to output as a Logger with toParams
+// would have written it, e.g. to change the format, timestamp format, or
+// prefix of a log. Lines are encoded by the same code as a Logger with the
+// default level set and tag order, so the level is written in the level tag
+// and global tags are kept. Messages which span several lines and stack traces
+// are read as by Parser.ToJSON().
+func Transcode(input io.Reader, output io.Writer, fromParams Params, toParams Params) error {
+	p := NewParser(fromParams)
+	return p.transcode(FormatDecoder(fromParams.Format), input, output, toParams)
+}
+
+// Convert JSON format input to plain format output. The output is written with
+// the Params of the Parser, as by Transcode().
+func (this *Parser) JSONToPlain(input io.Reader, output io.Writer) error {
+	toParams := this.params
+	toParams.Format = FormatPlain
+	return this.transcode(FormatDecoder(FormatJSON), input, output, toParams)
+}
+
+// Convert input to output with toParams using a Decoder. Lines which cannot be
+// decoded are handled as set with SetStrict().
+func (this *Parser) transcode(dec Decoder, input io.Reader, output io.Writer, toParams Params) error {
+	if dec == nil || FormatEncoder(toParams.Format) == nil {
+		return fmt.Errorf("Invalid format")
+	}
+
+	logger := New(output, toParams.Prefix, toParams.Flag)
+	logger.params = toParams
+	logger.SetLevel(DefaultLevelSet.Levels()[0])
+	if this.levelTag != "" {
+		logger.SetLevelTag(this.levelTag)
+	}
+
+	r := this.newEntryReader(dec, input, "")
+	for {
+		e, err := r.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rec := this.record(e)
+
+		// levels which the Logger does not know are kept as a tag
+		level := rec.Level
+		if level != "" && !DefaultLevelSet.Contains(level) {
+			rec.Tags.Set(this.levelTag, level)
+			level = ""
+		}
+
+		caller := rec.Tags.Get("caller")
+		rec.Tags.Del("caller")
+		if toParams.Flag&Lshortfile != 0 {
+			if i := strings.LastIndexByte(caller, '/'); i >= 0 {
+				caller = caller[i+1:]
+			}
+		}
+
+		// restore the types of the tags written by LogError()
+		if stack := rec.Tags.Get(StackTag); stack != "" {
+			rec.Tags[StackTag] = stackTrace(stack)
+		}
+		if chain, ok := rec.Tags[ErrorChainTag].(string); ok {
+			rec.Tags[ErrorChainTag] = []string{chain}
+		}
+
+		err = logger.writeCaller(nil, rec.Time, 0, caller, level, rec.Message, rec.Tags)
+		if err != nil {
+			return err
+		}
+	}
+}